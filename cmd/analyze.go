@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"micromachine.dev/cmd-utils/lib/analyze"
+	"micromachine.dev/cmd-utils/lib/bundler"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+var analyzeTop int
+var analyzeHTML string
+
+// analyzeCmd represents the analyze command
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Builds the worker and reports what makes it large",
+	Long: `The analyze command runs ` + "`micromachine build`" + ` with esbuild's metafile enabled,
+writes it to .micromachine/meta.json and prints, for every output chunk, the
+packages and modules contributing the most bytes. Pass --html to also write a
+self-contained treemap of the bundle.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		bundle := newBundle(wranglerEnv(cmd, buildEnv))
+		bundle.Analyze = true
+
+		runBuild(bundle)
+		reportAnalysis(bundle)
+	},
+}
+
+// reportAnalysis prints the largest packages and modules of every chunk of
+// the metafile written by the last build, and writes the treemap if asked to.
+func reportAnalysis(bundle *bundler.Bundle) {
+	data, err := os.ReadFile(filepath.Join(rootDir, bundle.GetMetafilePath()))
+	if err != nil {
+		utils.LogWithColor(utils.Fail, "✗ No metafile was written, pass --bundle to analyze a worker that is copied as-is")
+		os.Exit(1)
+	}
+
+	metafile, err := analyze.ParseMetafile(data)
+	if err != nil {
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+		os.Exit(1)
+	}
+
+	report := metafile.Analyze()
+
+	for _, chunk := range report.Chunks {
+		utils.LogWithColor(utils.Cyan, fmt.Sprintf("%s  %s", chunk.Path, utils.FormatBytes(chunk.Bytes)))
+
+		utils.LogWithColor(utils.Default, "  Packages")
+		for _, pkg := range analyze.Top(chunk.Packages, analyzeTop) {
+			utils.LogWithColor(utils.Muted, fmt.Sprintf("    %10s  %5.1f%%  %s", utils.FormatBytes(pkg.Bytes), percent(pkg.Bytes, chunk.Bytes), pkg.Name))
+		}
+
+		utils.LogWithColor(utils.Default, "  Modules")
+		for _, module := range analyze.Top(chunk.Modules, analyzeTop) {
+			utils.LogWithColor(utils.Muted, fmt.Sprintf("    %10s  %5.1f%%  %s", utils.FormatBytes(module.Bytes), percent(module.Bytes, chunk.Bytes), module.Name))
+		}
+	}
+
+	utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Wrote `%s`", filepath.ToSlash(filepath.Clean(bundle.GetMetafilePath()))))
+
+	if analyzeHTML == "" {
+		return
+	}
+
+	file, err := os.Create(filepath.Join(rootDir, analyzeHTML))
+	if err == nil {
+		err = report.WriteTreemap(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ Could not write treemap: %v", err))
+		os.Exit(1)
+	}
+
+	utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Wrote `%s`", analyzeHTML))
+}
+
+func percent(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
+
+func init() {
+	rootCmd.AddCommand(analyzeCmd)
+
+	analyzeCmd.PersistentFlags().StringVarP(&rootDir, "rootdir", "r", ".", "--rootdir ./apps/client")
+	analyzeCmd.PersistentFlags().StringVarP(&buildScript, "script", "s", "", "--s build")
+	analyzeCmd.PersistentFlags().StringVarP(&buildEnv, "env", "e", "", "--e staging")
+	analyzeCmd.PersistentFlags().StringVarP(&userDefinedEntrypoint, "entrypoint", "i", "", "--i ./dist/worker.js")
+	analyzeCmd.PersistentFlags().BoolVarP(&shouldBundle, "bundle", "b", false, "--bundle")
+	analyzeCmd.PersistentFlags().IntVarP(&analyzeTop, "top", "n", 10, "--top 10")
+	analyzeCmd.PersistentFlags().StringVar(&analyzeHTML, "html", "", "--html .micromachine/analyze.html")
+	analyzeCmd.PersistentFlags().Lookup("html").NoOptDefVal = ".micromachine/analyze.html"
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"micromachine.dev/cmd-utils/lib/bundler"
+	"micromachine.dev/cmd-utils/lib/frameworks"
 	"micromachine.dev/cmd-utils/lib/utils"
 )
 
@@ -16,115 +18,192 @@ var buildScript string
 var buildEnv string
 var userDefinedEntrypoint string
 var shouldBundle bool
+var generateTypes bool
+var buildAnalyze bool
+var injectVitePlugin bool
+var buildOffline bool
+var buildInstall bool
+var buildFrozen bool
+var sizeBudgetFlags utils.SizeBudgetConfig
 
 // buildCmd represents the build command
 var buildCmd = &cobra.Command{
-	Use:   "build",
+	Use:   "build [patterns...]",
 	Short: "Bundles the code for deployment",
 	Long: `The build command automates the preparation of your application for deployment.
 It performs the following steps:
-1. Detects the project's package manager (Bun, PNPM, or Yarn).
+1. Detects the project's package manager (Bun, PNPM, Yarn, or Deno).
 2. Locates and parses the wrangler configuration file (toml, json, or jsonc).
-3. Executes the specified build script.
-4. Bundles the resulting assets and entrypoints into a deployable package.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		packageManager, err := utils.DetectPackageManager(&rootDir)
-
-		if err != nil {
-			slog.Error(fmt.Sprintf("✗ %v", err))
-			os.Exit(1)
-		}
-
-		wrangler, err := utils.DetectWranglerFile[utils.WranglerConfig](&rootDir)
-
-		if err != nil {
-			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
-			os.Exit(2)
-		}
+3. Runs the build of the detected framework (Next.js, Nuxt, Astro, SvelteKit, React Router, TanStack Start, Waku), the specified build script, or the [build] command of the wrangler configuration.
+4. Bundles the resulting assets and entrypoints into a deployable package.
 
-		var wranglerEntrypoint string
-		if wrangler != nil && wrangler.Main != "" {
-			wranglerEntrypoint = wrangler.Main
-		}
-
-		var assetPath string
-
-		if wrangler != nil && wrangler.Assets != nil && wrangler.Assets.Directory != "" {
-			assetPath = wrangler.Assets.Directory
+With --all, or with glob patterns of project directories relative to --rootdir,
+every project with a wrangler configuration is built concurrently and a summary
+of the builds is printed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if buildAll || len(args) > 0 {
+			runBuildAll(cmd, args)
 		}
 
-		var entrypoint string
+		bundle := newBundle(wranglerEnv(cmd, buildEnv))
+		bundle.Analyze = buildAnalyze
+		bundle.SizeBudget = resolveSizeBudget()
 
-		if wranglerEntrypoint != "" {
-			entrypoint = wranglerEntrypoint
-		}
+		start := time.Now()
+		utils.LogWithColor(utils.Cyan, "Running `micromachine build`...")
 
-		if userDefinedEntrypoint != "" {
-			entrypoint = userDefinedEntrypoint
-		}
+		runBuild(bundle)
 
-		if entrypoint == "" {
-			slog.Error("✗ No entrypoint not found")
-			os.Exit(2)
+		if buildAnalyze {
+			reportAnalysis(bundle)
 		}
 
-		bundle := bundler.Bundle{
-			RootDir:        rootDir,
-			AssetPath:      assetPath,
-			ModulePath:     entrypoint,
-			PackageManager: *packageManager,
-			BuildScript:    buildScript,
-			Environment:    buildEnv,
-			WranglerConfig: wrangler,
-			ShouldBundle:   shouldBundle,
-		}
+		elapsed := time.Since(start)
 
-		start := time.Now()
-		utils.LogWithColor(utils.Cyan, "Running `micromachine build`...")
+		utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Completed `micromachine build` in %s", elapsed))
+		os.Exit(0)
+	},
+}
 
-		switch true {
-		case utils.IsNextJS(rootDir):
-			// Run open-next-build
-			start := time.Now()
-			utils.LogWithColor(utils.Default, "Running `opennextjs-cloudflare build`...")
-
-			// Install opennextjs/cloudflare for next
-			err := bundle.RunCommand(*packageManager, "--silent", "install", "@opennextjs/cloudflare")
-			if err != nil {
-				utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
-				os.Exit(1)
-			}
-
-			// Run build
-			err = bundle.RunCommand(*packageManager, "opennextjs-cloudflare", "build")
-			if err != nil {
-				utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
-				os.Exit(1)
-			}
-
-			// Calculate time elapsed.
-			elapsed := time.Since(start)
-			utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Completed `opennextjs-cloudflare build` in %s", elapsed))
-		default:
-			if bundle.BuildScript != "" {
-				err := bundle.RunBuildCommand()
-				if err != nil {
-					return
-				}
-			}
+// runBuild runs the build of the detected framework, then packs the output of
+// the bundle. It exits the process when any step fails.
+func runBuild(bundle *bundler.Bundle) {
+	if buildInstall {
+		if err := bundle.InstallDependencies(); err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+			os.Exit(1)
 		}
+	}
 
-		err = bundle.Pack()
+	if generateTypes || (bundle.WranglerConfig.Dev != nil && bundle.WranglerConfig.Dev.GenerateTypes) {
+		path, err := utils.WriteEnvTypes(rootDir, "worker-configuration.d.ts")
 		if err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
 			os.Exit(1)
-			return
 		}
+		utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Wrote `%s`", path))
+	}
+
+	if err := bundle.Framework.Build(bundle); err != nil {
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+		os.Exit(1)
+	}
+
+	if err := bundle.Framework.PostProcess(bundle); err != nil {
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+		os.Exit(1)
+	}
+
+	err := bundle.Pack()
+	if errors.Is(err, bundler.ErrSizeBudgetExceeded) {
+		os.Exit(3)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
 
-		elapsed := time.Since(start)
+// resolveSizeBudget merges the size budget of the package.json of rootDir
+// with the one given on the command line.
+func resolveSizeBudget() *utils.SizeBudget {
+	config, err := utils.ReadSizeBudgetConfig(rootDir)
+	if err != nil {
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+		os.Exit(2)
+	}
+
+	budget, err := config.Merge(sizeBudgetFlags).Resolve()
+	if err != nil {
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+		os.Exit(2)
+	}
+
+	return budget
+}
 
-		utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Completed `micromachine build` in %s", elapsed))
-		os.Exit(0)
-	},
+// wranglerEnv returns the wrangler environment selected with --env, or an
+// empty string, for the top-level configuration, when the flag is not passed.
+func wranglerEnv(cmd *cobra.Command, env string) string {
+	if !cmd.Flags().Changed("env") {
+		return ""
+	}
+
+	return env
+}
+
+// newBundle detects the package manager and wrangler configuration of
+// rootDir and resolves the entrypoint to bundle. It exits the process when
+// any of them is missing.
+func newBundle(environment string) *bundler.Bundle {
+	packageManager, err := utils.DetectPackageManager(&rootDir)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("✗ %v", err))
+		os.Exit(1)
+	}
+
+	workspace, err := utils.FindWorkspace(rootDir)
+	if err != nil {
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+		os.Exit(2)
+	}
+	if workspace != nil {
+		utils.LogWithColor(utils.Default, fmt.Sprintf("Detected workspace root \033[1m`%s`\033[0m", workspace.RelRoot(rootDir)))
+	}
+	if pnp := utils.FindPnp(rootDir); pnp != nil {
+		utils.LogWithColor(utils.Default, fmt.Sprintf("Detected Yarn Plug'n'Play install \033[1m`%s`\033[0m", pnp.RelManifest(rootDir)))
+	}
+
+	wrangler, err := utils.DetectWranglerFile[utils.WranglerConfig](&rootDir)
+
+	if err != nil {
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+		os.Exit(2)
+	}
+
+	wrangler = wrangler.ForEnvironment(environment)
+
+	var wranglerEntrypoint string
+	if wrangler != nil && wrangler.Main != "" {
+		wranglerEntrypoint = wrangler.Main
+	}
+
+	var assetPath string
+
+	if wrangler != nil && wrangler.Assets != nil && wrangler.Assets.Directory != "" {
+		assetPath = wrangler.Assets.Directory
+	}
+
+	var entrypoint string
+
+	if wranglerEntrypoint != "" {
+		entrypoint = wranglerEntrypoint
+	}
+
+	if userDefinedEntrypoint != "" {
+		entrypoint = userDefinedEntrypoint
+	}
+
+	if entrypoint == "" {
+		slog.Error("✗ No entrypoint not found")
+		os.Exit(2)
+	}
+
+	return &bundler.Bundle{
+		RootDir:          rootDir,
+		AssetPath:        assetPath,
+		ModulePath:       entrypoint,
+		PackageManager:   *packageManager,
+		BuildScript:      buildScript,
+		Environment:      environment,
+		WranglerConfig:   wrangler,
+		ShouldBundle:     shouldBundle,
+		Framework:        frameworks.Detect(rootDir),
+		Workspace:        workspace,
+		InjectVitePlugin: injectVitePlugin,
+		Offline:          buildOffline,
+		Frozen:           buildFrozen,
+	}
 }
 
 func init() {
@@ -140,7 +219,16 @@ func init() {
 	// is called directly, e.g.:
 	buildCmd.PersistentFlags().StringVarP(&rootDir, "rootdir", "r", ".", "--rootdir ./apps/client")
 	buildCmd.PersistentFlags().StringVarP(&buildScript, "script", "s", "", "--s build")
-	buildCmd.PersistentFlags().StringVarP(&buildEnv, "env", "e", "production", "--e production")
+	buildCmd.PersistentFlags().StringVarP(&buildEnv, "env", "e", "", "--e staging")
 	buildCmd.PersistentFlags().StringVarP(&userDefinedEntrypoint, "entrypoint", "i", "", "--i ./dist/worker.js")
 	buildCmd.PersistentFlags().BoolVarP(&shouldBundle, "bundle", "b", false, "--bundle")
+	buildCmd.PersistentFlags().BoolVar(&generateTypes, "types", false, "--types")
+	buildCmd.PersistentFlags().BoolVar(&buildAnalyze, "analyze", false, "--analyze")
+	buildCmd.PersistentFlags().BoolVar(&injectVitePlugin, "vite-plugin", false, "--vite-plugin")
+	buildCmd.PersistentFlags().BoolVar(&buildOffline, "offline", false, "--offline")
+	buildCmd.PersistentFlags().BoolVar(&buildInstall, "install", false, "--install")
+	buildCmd.PersistentFlags().BoolVar(&buildFrozen, "frozen", false, "--frozen")
+	buildCmd.PersistentFlags().StringVar(&sizeBudgetFlags.Plan, "plan", "", "--plan free|paid")
+	buildCmd.PersistentFlags().StringVar(&sizeBudgetFlags.Max, "max-size", "", "--max-size 8MiB")
+	buildCmd.PersistentFlags().StringVar(&sizeBudgetFlags.Warn, "warn-size", "", "--warn-size 6MiB")
 }
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"micromachine.dev/cmd-utils/lib/batch"
+	"micromachine.dev/cmd-utils/lib/bundler"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+var buildAll bool
+var buildParallel int
+
+// batchFlags are the build flags that select the projects, or that run once
+// for all of them, and are not passed on to the build of each of them.
+var batchFlags = []string{"rootdir", "all", "parallel", "entrypoint", "install"}
+
+// runBuildAll builds every project of rootDir, or the ones matching the
+// patterns, each in a `micromachine build` process of its own, then prints a
+// summary. It exits with 1 when any build failed.
+func runBuildAll(cmd *cobra.Command, patterns []string) {
+	projects, err := batch.FindProjects(rootDir, patterns)
+	if err != nil {
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+		os.Exit(2)
+	}
+	if len(projects) == 0 {
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ No wrangler configuration found in `%s`", rootDir))
+		os.Exit(2)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+		os.Exit(1)
+	}
+
+	if buildInstall {
+		installProjects(projects)
+	}
+
+	var forwarded []string
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if !slices.Contains(batchFlags, flag.Name) {
+			forwarded = append(forwarded, fmt.Sprintf("--%s=%s", flag.Name, flag.Value.String()))
+		}
+	})
+
+	start := time.Now()
+	utils.LogWithColor(utils.Cyan, fmt.Sprintf("Building %d projects, %d at a time...", len(projects), buildParallel))
+
+	runner := &batch.Runner{
+		Parallel: buildParallel,
+		Output:   os.Stdout,
+		Command: func(project batch.Project) *exec.Cmd {
+			args := append([]string{"build", "--rootdir", project.Dir}, forwarded...)
+			return exec.Command(executable, args...)
+		},
+	}
+
+	results := runner.Run(projects)
+
+	failed := 0
+	utils.LogWithColor(utils.Default, fmt.Sprintf("%-30s %-16s %10s %12s", "Project", "Status", "Duration", "Gzip"))
+	for _, result := range results {
+		size := "-"
+		if result.Err == nil || result.ExitCode == 3 {
+			if gzipped, err := packedSize(result.Dir); err == nil {
+				size = utils.FormatBytes(gzipped)
+			}
+		}
+
+		status, style := "✓ built", utils.Success
+		switch {
+		case result.ExitCode == 3:
+			status, style = "✗ over budget", utils.Fail
+		case result.Err != nil:
+			status, style = fmt.Sprintf("✗ failed (%d)", result.ExitCode), utils.Fail
+		}
+		if result.Err != nil {
+			failed++
+		}
+
+		utils.LogWithColor(style, fmt.Sprintf("%-30s %-16s %10s %12s", result.Name, status, result.Duration.Round(time.Millisecond), size))
+	}
+
+	elapsed := time.Since(start)
+	if failed > 0 {
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %d of %d projects failed in %s", failed, len(results), elapsed))
+		os.Exit(1)
+	}
+
+	utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Built %d projects in %s", len(results), elapsed))
+	os.Exit(0)
+}
+
+// packedSize returns the gzip size of the worker packed in dir.
+func packedSize(dir string) (int64, error) {
+	sizes, err := (&bundler.Bundle{RootDir: dir}).MeasureModuleSize()
+	if err != nil {
+		return 0, err
+	}
+
+	var gzipped int64
+	for _, size := range sizes {
+		gzipped += size.Gzip
+	}
+
+	return gzipped, nil
+}
+
+func init() {
+	buildCmd.Flags().BoolVar(&buildAll, "all", false, "--all")
+	buildCmd.Flags().IntVarP(&buildParallel, "parallel", "j", runtime.NumCPU(), "--parallel 4")
+}
+
+// installProjects runs the frozen install of every project before the builds,
+// once per directory so that the members of a workspace, which share its
+// root, are not installed concurrently.
+func installProjects(projects []batch.Project) {
+	bundles := make([]*bundler.Bundle, 0, len(projects))
+	names := map[*bundler.Bundle]string{}
+
+	for _, project := range projects {
+		packageManager, _, err := utils.ResolvePackageManager(project.Dir)
+		if err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %s: %v", project.Name, err))
+			os.Exit(1)
+		}
+
+		workspace, err := utils.FindWorkspace(project.Dir)
+		if err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %s: %v", project.Name, err))
+			os.Exit(2)
+		}
+
+		bundle := &bundler.Bundle{RootDir: project.Dir, PackageManager: packageManager, Workspace: workspace}
+		bundles = append(bundles, bundle)
+		names[bundle] = project.Name
+	}
+
+	for _, bundle := range bundler.UniqueInstalls(bundles) {
+		if err := bundle.InstallDependencies(); err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %s: %v", names[bundle], err))
+			os.Exit(1)
+		}
+	}
+}
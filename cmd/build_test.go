@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// useProject writes the files of a project to a temporary directory and
+// makes it the --rootdir of the commands.
+func useProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previous := rootDir
+	rootDir = dir
+	t.Cleanup(func() {
+		rootDir = previous
+	})
+
+	return dir
+}
+
+func TestNewBundleEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"No --env", nil, "src/index.ts"},
+		{"--env production", []string{"--env", "production"}, "src/production.ts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useProject(t, map[string]string{
+				"package.json":      `{}`,
+				"package-lock.json": `{}`,
+				"wrangler.jsonc":    `{"name": "worker", "main": "src/index.ts", "env": {"production": {"main": "src/production.ts"}}}`,
+			})
+
+			var env string
+			cmd := &cobra.Command{}
+			cmd.Flags().StringVarP(&env, "env", "e", "", "")
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			bundle := newBundle(wranglerEnv(cmd, env))
+			if bundle.ModulePath != tt.expected {
+				t.Errorf("Expected the entrypoint %s, got %s", tt.expected, bundle.ModulePath)
+			}
+		})
+	}
+}
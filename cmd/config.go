@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+var configEnv string
+var configFormat string
+var configStrict bool
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspects the wrangler configuration",
+	Long: `The config command helps debugging the wrangler configuration micromachine reads.
+Use its subcommands to print the configuration resolved for an environment,
+validate the configuration file or explain where a value comes from.`,
+}
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Prints the resolved wrangler configuration",
+	Run: func(cmd *cobra.Command, args []string) {
+		wrangler, err := utils.DetectWranglerFile[utils.WranglerConfig](&rootDir)
+		if err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+			os.Exit(2)
+		}
+
+		normalized := wrangler.ForEnvironment(wranglerEnv(cmd, configEnv)).Normalize()
+
+		data, err := json.MarshalIndent(normalized, "", "  ")
+		if err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+			os.Exit(1)
+		}
+
+		switch configFormat {
+		case "json":
+		case "toml":
+			// Go through JSON so empty values are omitted like in the JSON output.
+			var values map[string]any
+			if err := json.Unmarshal(data, &values); err == nil {
+				data, err = toml.Marshal(values)
+			}
+			if err != nil {
+				utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+				os.Exit(1)
+			}
+		default:
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ Unknown format `%s`, expected `json` or `toml`", configFormat))
+			os.Exit(2)
+		}
+
+		fmt.Println(string(data))
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Reports unknown keys, wrong types and missing fields",
+	Run: func(cmd *cobra.Command, args []string) {
+		src, err := utils.ParseWranglerSource(&rootDir)
+		if err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+			os.Exit(2)
+		}
+
+		problems := utils.ValidateWranglerSource(src)
+
+		errors := 0
+		for _, problem := range problems {
+			color := utils.Warning
+			if problem.Severity == utils.SeverityError {
+				color = utils.Fail
+				errors++
+			}
+			utils.LogWithColor(color, problem.String())
+		}
+
+		if errors > 0 || (configStrict && len(problems) > 0) {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ Found %d error(s) and %d warning(s) in `%s`", errors, len(problems)-errors, src.Path))
+			os.Exit(1)
+		}
+
+		if len(problems) > 0 {
+			utils.LogWithColor(utils.Success, fmt.Sprintf("✓ `%s` is valid, with %d warning(s)", src.Path, len(problems)))
+			return
+		}
+
+		utils.LogWithColor(utils.Success, fmt.Sprintf("✓ `%s` is valid", src.Path))
+	},
+}
+
+var configExplainCmd = &cobra.Command{
+	Use:   "explain <key>",
+	Short: "Explains where the value of a key comes from",
+	Example: `  micromachine config explain main
+  micromachine config explain vars.API_URL --env staging
+  micromachine config explain kv_namespaces[0].id`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		src, err := utils.ParseWranglerSource(&rootDir)
+		if err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+			os.Exit(2)
+		}
+
+		origin, err := utils.ExplainWranglerKey(src, wranglerEnv(cmd, configEnv), args[0])
+		if err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+			os.Exit(1)
+		}
+
+		value, _ := json.Marshal(origin.Value)
+		utils.LogWithColor(utils.Default, fmt.Sprintf("%s = %s", origin.Key, value))
+		utils.LogWithColor(utils.Muted, fmt.Sprintf("  from `%s` in %s:%d:%d", origin.Block, origin.Path, origin.Line, origin.Column))
+		if origin.Note != "" {
+			utils.LogWithColor(utils.Muted, "  "+origin.Note)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configPrintCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configExplainCmd)
+
+	configCmd.PersistentFlags().StringVarP(&rootDir, "rootdir", "r", ".", "--rootdir ./apps/client")
+	configCmd.PersistentFlags().StringVarP(&configEnv, "env", "e", "", "--e staging")
+	configPrintCmd.Flags().StringVarP(&configFormat, "format", "f", "json", "--format json|toml")
+	configValidateCmd.Flags().BoolVar(&configStrict, "strict", false, "--strict")
+}
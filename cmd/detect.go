@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"micromachine.dev/cmd-utils/lib/bundler"
+	"micromachine.dev/cmd-utils/lib/frameworks"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+var detectJSON bool
+
+// detectDecision is one auto-detection decision and what it was based on.
+type detectDecision struct {
+	Value    any    `json:"value"`
+	Evidence string `json:"evidence,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// detectReport holds every decision `build` would make for a project.
+type detectReport struct {
+	RootDir        string          `json:"rootDir"`
+	Workspace      *detectDecision `json:"workspace,omitempty"`
+	PackageManager *detectDecision `json:"packageManager"`
+	Pnp            *detectDecision `json:"pnp,omitempty"`
+	WranglerFile   *detectDecision `json:"wranglerFile"`
+	Framework      *detectDecision `json:"framework"`
+	BuildCommand   *detectDecision `json:"buildCommand,omitempty"`
+	Vite           *detectDecision `json:"vite,omitempty"`
+	Entrypoint     *detectDecision `json:"entrypoint"`
+	ServerOutput   *detectDecision `json:"serverOutput"`
+	Assets         *detectDecision `json:"assets"`
+	Bundle         *detectDecision `json:"bundle"`
+}
+
+// detectCmd represents the detect command
+var detectCmd = &cobra.Command{
+	Use:   "detect",
+	Short: "Explains what micromachine detects in a project",
+	Long: `The detect command runs every auto-detection of the build without building:
+the workspace root, the package manager, the wrangler configuration file, the framework, the
+custom build command, the vite plugin, the entrypoint, the server output and assets directories, and whether
+the worker is bundled. Every decision is printed with the evidence it is based on.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		report := detectProject(wranglerEnv(cmd, buildEnv))
+
+		if detectJSON {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		printDetectReport(report)
+	},
+}
+
+// detectProject runs the detections of newBundle for the wrangler
+// environment env without logging or exiting, recording the evidence of each
+// of them.
+func detectProject(env string) *detectReport {
+	report := &detectReport{RootDir: rootDir}
+
+	workspace, err := utils.FindWorkspace(rootDir)
+	switch {
+	case err != nil:
+		report.Workspace = newDetectDecision(nil, "", err)
+	case workspace != nil:
+		evidence := workspace.Evidence
+		if workspace.Package != "" {
+			evidence += fmt.Sprintf(", scripts run with the filter of `%s`", workspace.Package)
+		}
+		report.Workspace = newDetectDecision(workspace.RelRoot(rootDir), evidence, nil)
+	}
+
+	packageManager, evidence, err := utils.ResolvePackageManager(rootDir)
+	if evidence == "" {
+		evidence = "no `packageManager` field in package.json and no lockfile, falling back to npm"
+	}
+	report.PackageManager = newDetectDecision(packageManager.String(), evidence, err)
+
+	if pnp := utils.FindPnp(rootDir); pnp != nil {
+		report.Pnp = newDetectDecision(pnp.RelManifest(rootDir), pnp.Evidence()+", packages are resolved through the Plug'n'Play data", nil)
+	}
+
+	conf := &utils.WranglerConfig{}
+	wranglerPath, err := utils.FindWranglerFile(&rootDir)
+	if err == nil {
+		conf, err = utils.DetectWranglerFile[utils.WranglerConfig](&rootDir)
+	}
+	report.WranglerFile = newDetectDecision(filepath.Base(wranglerPath), wranglerEvidence(wranglerPath, conf, env), err)
+
+	wrangler := &utils.WranglerConfig{}
+	if err == nil {
+		wrangler = conf.ForEnvironment(env)
+	}
+
+	adapter, evidence := frameworks.Match(rootDir)
+	report.Framework = &detectDecision{Value: adapter.Name(), Evidence: evidence}
+
+	if build := wrangler.Build; build != nil && build.Command != "" {
+		report.BuildCommand = detectBuildCommand(build, wranglerPath)
+	}
+
+	if path, ok := utils.IsViteApp(rootDir); ok {
+		report.Vite = detectVitePlugin(*path)
+	}
+
+	entrypoint := wrangler.Main
+	if userDefinedEntrypoint != "" {
+		entrypoint = userDefinedEntrypoint
+	}
+
+	assetPath := ""
+	if wrangler.Assets != nil {
+		assetPath = wrangler.Assets.Directory
+	}
+
+	bundle := &bundler.Bundle{
+		RootDir:        rootDir,
+		AssetPath:      assetPath,
+		ModulePath:     entrypoint,
+		PackageManager: packageManager,
+		Environment:    env,
+		WranglerConfig: wrangler,
+		ShouldBundle:   shouldBundle,
+		Framework:      adapter,
+		Workspace:      workspace,
+	}
+
+	// Reflect the output of the framework build, as PostProcess would.
+	framework, hasOutput := adapter.(bundler.OutputFramework)
+	if hasOutput {
+		bundle.UseFrameworkOutput(framework.Output(bundle))
+	}
+
+	// Reflect the output of a previous build, as Pack would.
+	_ = bundle.LoadBuildWranglerConfig()
+
+	modulePath, evidence, err := bundle.ExplainModulePath()
+	switch {
+	case err == nil && modulePath == "":
+		err = fmt.Errorf("no entrypoint found, set `main` in the wrangler configuration or pass --entrypoint")
+	case evidence == "" && hasOutput:
+		evidence = fmt.Sprintf("output of the `%s` framework, whatever `main` is set to", adapter.Name())
+	case evidence == "" && userDefinedEntrypoint != "":
+		evidence = "given with --entrypoint"
+	case evidence == "":
+		evidence = fmt.Sprintf("`main` in `%s`", filepath.Base(wranglerPath))
+	}
+	report.Entrypoint = newDetectDecision(modulePath, evidence, err)
+
+	moduleDir, evidence, err := bundle.LocateModuleDir()
+	report.ServerOutput = newDetectDecision(moduleDir, evidence, err)
+
+	assets, evidence, err := bundle.LocateAssets()
+	report.Assets = newDetectDecision(assets, evidence, err)
+
+	bundled, evidence := bundle.ExplainBundling()
+	report.Bundle = &detectDecision{Value: bundled, Evidence: evidence}
+
+	return report
+}
+
+func newDetectDecision(value any, evidence string, err error) *detectDecision {
+	if err != nil {
+		return &detectDecision{Error: err.Error()}
+	}
+
+	return &detectDecision{Value: value, Evidence: evidence}
+}
+
+// wranglerEvidence tells which of the wrangler files of the root directory is
+// used, and whether the environment of the build is merged into it.
+func wranglerEvidence(path string, conf *utils.WranglerConfig, env string) string {
+	if path == "" {
+		return ""
+	}
+
+	evidence := "found `" + filepath.Base(path) + "`"
+
+	var ignored []string
+	for _, name := range []string{"wrangler.toml", "wrangler.json", "wrangler.jsonc"} {
+		if name == filepath.Base(path) {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(rootDir, name)); err == nil && info.Size() > 0 {
+			ignored = append(ignored, "`"+name+"`")
+		}
+	}
+	if len(ignored) > 0 {
+		evidence += ", ignoring " + strings.Join(ignored, " and ")
+	}
+
+	if conf == nil {
+		return evidence
+	}
+	if _, ok := conf.Env[env]; ok {
+		evidence += fmt.Sprintf(", merged with `env.%s`", env)
+	}
+
+	return evidence
+}
+
+// detectBuildCommand reports the custom build of the `[build]` section of the
+// wrangler configuration.
+func detectBuildCommand(build *utils.BuildConfig, wranglerPath string) *detectDecision {
+	cwd := build.Cwd
+	if cwd == "" {
+		cwd = "."
+	}
+	watchDir := build.WatchDir
+	if watchDir == "" {
+		watchDir = "src"
+	}
+
+	return &detectDecision{
+		Value:    build.Command,
+		Evidence: fmt.Sprintf("`build.command` in `%s`, run from `%s` unless --script is given, and by dev on changes of `%s`", filepath.Base(wranglerPath), cwd, watchDir),
+	}
+}
+
+// detectVitePlugin reports whether the vite config at path uses
+// `@cloudflare/vite-plugin`.
+func detectVitePlugin(path string) *detectDecision {
+	decision := &detectDecision{Value: filepath.Base(path)}
+
+	checks, err := utils.HasCloudflareVitePlugin(rootDir)
+	switch {
+	case err != nil:
+		decision.Error = fmt.Sprintf("could not resolve the vite config: %v", err)
+	case checks.IsPlugin:
+		decision.Evidence = "uses `@cloudflare/vite-plugin`"
+	case checks.HasDependency:
+		decision.Evidence = "`@cloudflare/vite-plugin` is installed but not used, add it to the config or build with --vite-plugin"
+	default:
+		decision.Evidence = "`@cloudflare/vite-plugin` is not installed, build with --vite-plugin to add it"
+	}
+
+	return decision
+}
+
+func printDetectReport(report *detectReport) {
+	rows := []struct {
+		label    string
+		decision *detectDecision
+	}{
+		{"Workspace root", report.Workspace},
+		{"Package manager", report.PackageManager},
+		{"Plug'n'Play", report.Pnp},
+		{"Wrangler file", report.WranglerFile},
+		{"Framework", report.Framework},
+		{"Build command", report.BuildCommand},
+		{"Vite config", report.Vite},
+		{"Entrypoint", report.Entrypoint},
+		{"Server output", report.ServerOutput},
+		{"Assets", report.Assets},
+		{"Bundle", report.Bundle},
+	}
+
+	for _, row := range rows {
+		if row.decision == nil {
+			continue
+		}
+
+		if row.decision.Error != "" {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("%-16s ✗ %s", row.label, row.decision.Error))
+			continue
+		}
+
+		value := fmt.Sprintf("%v", row.decision.Value)
+		if value == "" {
+			value = "none"
+		}
+
+		utils.LogWithColor(utils.Default, fmt.Sprintf("%-16s \033[1m%s\033[0m", row.label, value))
+		if row.decision.Evidence != "" {
+			utils.LogWithColor(utils.Muted, fmt.Sprintf("%-16s %s", "", row.decision.Evidence))
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(detectCmd)
+
+	detectCmd.PersistentFlags().StringVarP(&rootDir, "rootdir", "r", ".", "--rootdir ./apps/client")
+	detectCmd.PersistentFlags().StringVarP(&buildEnv, "env", "e", "", "--e staging")
+	detectCmd.PersistentFlags().StringVarP(&userDefinedEntrypoint, "entrypoint", "i", "", "--i ./dist/worker.js")
+	detectCmd.PersistentFlags().BoolVarP(&shouldBundle, "bundle", "b", false, "--bundle")
+	detectCmd.Flags().BoolVar(&detectJSON, "json", false, "--json")
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectProjectFrameworkOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		entrypoint string
+		bundled    bool
+	}{
+		{
+			"Worker",
+			map[string]string{
+				"package.json":      `{}`,
+				"package-lock.json": `{}`,
+				"wrangler.jsonc":    `{"name": "worker", "main": "src/index.js"}`,
+			},
+			"src/index.js",
+			false,
+		},
+		{
+			"Astro",
+			map[string]string{
+				"package.json":      `{"dependencies": {"astro": "^5.0.0", "@astrojs/cloudflare": "^12.0.0"}}`,
+				"package-lock.json": `{}`,
+				"astro.config.mjs":  "import cloudflare from '@astrojs/cloudflare';\nexport default defineConfig({ adapter: cloudflare() })",
+				"wrangler.jsonc":    `{"name": "astro", "main": ".micromachine/worker/index.js"}`,
+			},
+			"dist/_worker.js/index.js",
+			false,
+		},
+		{
+			"SvelteKit",
+			map[string]string{
+				"package.json":      `{"devDependencies": {"@sveltejs/kit": "^2.0.0", "@sveltejs/adapter-cloudflare": "^7.0.0"}}`,
+				"package-lock.json": `{}`,
+				"svelte.config.js":  "import adapter from '@sveltejs/adapter-cloudflare';\nexport default { kit: { adapter: adapter() } };",
+				"wrangler.jsonc":    `{"name": "svelte", "main": ".svelte-kit/cloudflare/_worker.js"}`,
+			},
+			".svelte-kit/cloudflare/_worker.js",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useProject(t, tt.files)
+
+			report := detectProject("")
+			if report.Entrypoint.Error != "" {
+				t.Fatalf("Entrypoint error = %s", report.Entrypoint.Error)
+			}
+			if got := report.Entrypoint.Value; got != filepath.FromSlash(tt.entrypoint) {
+				t.Errorf("Entrypoint = %v, want %s (%s)", got, tt.entrypoint, report.Entrypoint.Evidence)
+			}
+			if got := report.Bundle.Value; got != tt.bundled {
+				t.Errorf("Bundle = %v, want %v (%s)", got, tt.bundled, report.Bundle.Evidence)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+var devEnv string
+
+// devCmd represents the dev command
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Rebuilds the worker on every change",
+	Long: `The dev command keeps an esbuild context alive and incrementally rebuilds
+.micromachine/worker whenever one of the worker sources changes.
+Changed assets are re-copied one by one, and the wrangler configuration
+is re-read whenever it is modified.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		bundle := newBundle(wranglerEnv(cmd, devEnv))
+		bundle.NodeEnv = "development"
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		utils.LogWithColor(utils.Cyan, "Running `micromachine dev`...")
+
+		err := bundle.Watch(ctx)
+		if err != nil {
+			stop()
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(devCmd)
+
+	devCmd.PersistentFlags().StringVarP(&rootDir, "rootdir", "r", ".", "--rootdir ./apps/client")
+	devCmd.PersistentFlags().StringVarP(&devEnv, "env", "e", "", "--e staging")
+	devCmd.PersistentFlags().StringVarP(&userDefinedEntrypoint, "entrypoint", "i", "", "--i ./dist/worker.js")
+	devCmd.PersistentFlags().BoolVarP(&shouldBundle, "bundle", "b", false, "--bundle")
+}
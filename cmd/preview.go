@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"micromachine.dev/cmd-utils/lib/preview"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+var previewIP string
+var previewPort int
+
+// previewCmd represents the preview command
+var previewCmd = &cobra.Command{
+	Use:   "preview",
+	Short: "Serves the built output locally",
+	Long: `The preview command serves the output of ` + "`micromachine build`" + ` exactly as it will be deployed.
+Requests matching a file in .micromachine/assets are answered following the
+html_handling and not_found_handling settings of the wrangler assets configuration.
+Every other request is forwarded to a local worker runtime (workerd or miniflare,
+when found on PATH) running the bundled module from .micromachine/worker.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		bundle := newBundle(wranglerEnv(cmd, buildEnv))
+
+		absDir, err := filepath.Abs(rootDir)
+		if err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+			os.Exit(1)
+		}
+
+		workerDir := filepath.Join(absDir, bundle.GetModuleDir())
+		if _, err := os.Stat(workerDir); err != nil {
+			utils.LogWithColor(utils.Fail, "✗ No build output found, run `micromachine build` first")
+			os.Exit(2)
+		}
+
+		// The framework may have selected another entrypoint than `main`
+		// during the build, so serve the one Pack wrote.
+		mainModule, err := bundle.PackedModuleEntry()
+		if err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+			os.Exit(2)
+		}
+
+		assetDir, err := filepath.Abs(bundle.GetAssetDir())
+		if err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		err = preview.Serve(ctx, preview.Options{
+			Addr:           net.JoinHostPort(previewIP, strconv.Itoa(previewPort)),
+			AssetDir:       assetDir,
+			WorkerDir:      workerDir,
+			MainModule:     mainModule,
+			WranglerConfig: bundle.WranglerConfig,
+		})
+		if err != nil {
+			stop()
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(previewCmd)
+
+	previewCmd.PersistentFlags().StringVarP(&rootDir, "rootdir", "r", ".", "--rootdir ./apps/client")
+	previewCmd.PersistentFlags().StringVarP(&buildEnv, "env", "e", "", "--e staging")
+	previewCmd.PersistentFlags().StringVarP(&userDefinedEntrypoint, "entrypoint", "i", "", "--i ./dist/worker.js")
+	previewCmd.PersistentFlags().BoolVarP(&shouldBundle, "bundle", "b", false, "--bundle")
+	previewCmd.PersistentFlags().StringVar(&previewIP, "ip", "localhost", "--ip 0.0.0.0")
+	previewCmd.PersistentFlags().IntVarP(&previewPort, "port", "p", 8787, "--port 8787")
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+var typesOutput string
+
+// typesCmd represents the types command
+var typesCmd = &cobra.Command{
+	Use:   "types",
+	Short: "Generates TypeScript declarations for the worker bindings",
+	Long: `The types command walks every binding of the wrangler configuration (KV, R2, D1,
+Durable Objects, services, queues, Hyperdrive, Vectorize, AI, workflows, vars and
+the secrets declared in .dev.vars) and writes an ` + "`Env`" + ` interface for the
+top-level configuration and for every environment.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := utils.WriteEnvTypes(rootDir, typesOutput)
+		if err != nil {
+			utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ %v", err))
+			os.Exit(1)
+		}
+
+		utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Wrote `%s`", path))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(typesCmd)
+
+	typesCmd.PersistentFlags().StringVarP(&rootDir, "rootdir", "r", ".", "--rootdir ./apps/client")
+	typesCmd.PersistentFlags().StringVarP(&typesOutput, "output", "o", "worker-configuration.d.ts", "--output worker-configuration.d.ts")
+}
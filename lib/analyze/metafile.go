@@ -0,0 +1,142 @@
+package analyze
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ProjectPackage groups the modules that do not come from `node_modules`.
+const ProjectPackage = "(project)"
+
+// Metafile is the subset of esbuild's metafile needed to attribute the size
+// of every output chunk to its inputs.
+type Metafile struct {
+	Inputs  map[string]MetafileInput  `json:"inputs"`
+	Outputs map[string]MetafileOutput `json:"outputs"`
+}
+
+type MetafileInput struct {
+	Bytes int64 `json:"bytes"`
+}
+
+type MetafileOutput struct {
+	Bytes      int64                          `json:"bytes"`
+	EntryPoint string                         `json:"entryPoint,omitempty"`
+	Inputs     map[string]MetafileOutputInput `json:"inputs"`
+}
+
+type MetafileOutputInput struct {
+	BytesInOutput int64 `json:"bytesInOutput"`
+}
+
+// Contribution is the number of bytes a module or a package adds to a chunk.
+type Contribution struct {
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Chunk is an output file of the bundle with its inputs, largest first.
+type Chunk struct {
+	Path       string         `json:"path"`
+	Bytes      int64          `json:"bytes"`
+	EntryPoint string         `json:"entryPoint,omitempty"`
+	Modules    []Contribution `json:"modules"`
+	Packages   []Contribution `json:"packages"`
+}
+
+// Report lists the chunks of a bundle, largest first.
+type Report struct {
+	Chunks []Chunk `json:"chunks"`
+}
+
+// ParseMetafile decodes the metafile esbuild returns in `BuildResult.Metafile`.
+func ParseMetafile(data []byte) (*Metafile, error) {
+	var metafile Metafile
+	if err := json.Unmarshal(data, &metafile); err != nil {
+		return nil, fmt.Errorf("could not parse metafile: %w", err)
+	}
+
+	return &metafile, nil
+}
+
+// Analyze attributes the bytes of every JavaScript chunk of the metafile to
+// its modules and to the packages they belong to. Source maps are skipped.
+func (m *Metafile) Analyze() *Report {
+	report := &Report{}
+
+	for path, output := range m.Outputs {
+		if strings.HasSuffix(path, ".map") {
+			continue
+		}
+
+		chunk := Chunk{
+			Path:       path,
+			Bytes:      output.Bytes,
+			EntryPoint: output.EntryPoint,
+		}
+
+		packages := map[string]int64{}
+		for input, contribution := range output.Inputs {
+			if contribution.BytesInOutput == 0 {
+				continue
+			}
+
+			chunk.Modules = append(chunk.Modules, Contribution{Name: input, Bytes: contribution.BytesInOutput})
+			packages[PackageName(input)] += contribution.BytesInOutput
+		}
+
+		for name, bytes := range packages {
+			chunk.Packages = append(chunk.Packages, Contribution{Name: name, Bytes: bytes})
+		}
+
+		sortContributions(chunk.Modules)
+		sortContributions(chunk.Packages)
+		report.Chunks = append(report.Chunks, chunk)
+	}
+
+	slices.SortFunc(report.Chunks, func(a, b Chunk) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), strings.Compare(a.Path, b.Path))
+	})
+
+	return report
+}
+
+// PackageName returns the npm package a module belongs to, e.g.
+// `node_modules/@scope/pkg/dist/index.js` belongs to `@scope/pkg`. Modules
+// outside of `node_modules` belong to ProjectPackage.
+func PackageName(path string) string {
+	// Strip esbuild namespaces such as `nodejs-hybrid:`.
+	if i := strings.LastIndex(path, ":"); i >= 0 && !strings.Contains(path[:i], "/") {
+		path = path[i+1:]
+	}
+
+	i := strings.LastIndex(path, "node_modules/")
+	if i < 0 {
+		return ProjectPackage
+	}
+
+	parts := strings.Split(path[i+len("node_modules/"):], "/")
+	if strings.HasPrefix(parts[0], "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+
+	return parts[0]
+}
+
+// Top returns at most n contributions.
+func Top(contributions []Contribution, n int) []Contribution {
+	if n <= 0 || len(contributions) <= n {
+		return contributions
+	}
+
+	return contributions[:n]
+}
+
+func sortContributions(contributions []Contribution) {
+	slices.SortFunc(contributions, func(a, b Contribution) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), strings.Compare(a.Name, b.Name))
+	})
+}
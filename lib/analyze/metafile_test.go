@@ -0,0 +1,104 @@
+package analyze
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPackageName(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"src/index.ts", ProjectPackage},
+		{"node_modules/hono/dist/index.js", "hono"},
+		{"node_modules/@scope/pkg/dist/index.js", "@scope/pkg"},
+		{"node_modules/.pnpm/react@19.0.0/node_modules/react/index.js", "react"},
+		{"nodejs-hybrid:node_modules/unenv/dist/runtime/node/fs.mjs", "unenv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := PackageName(tt.path); got != tt.expected {
+				t.Errorf("Expected package of %s to be %s, got %s", tt.path, tt.expected, got)
+			}
+		})
+	}
+}
+
+const testMetafile = `{
+	"inputs": {
+		"src/index.ts": {"bytes": 200},
+		"src/routes.ts": {"bytes": 100},
+		"node_modules/hono/dist/index.js": {"bytes": 4000},
+		"node_modules/hono/dist/router.js": {"bytes": 3000}
+	},
+	"outputs": {
+		".micromachine/worker/index.js.map": {"bytes": 9000, "inputs": {}},
+		".micromachine/worker/index.js": {
+			"bytes": 5000,
+			"entryPoint": "src/index.ts",
+			"inputs": {
+				"src/index.ts": {"bytesInOutput": 150},
+				"node_modules/hono/dist/index.js": {"bytesInOutput": 2500},
+				"node_modules/hono/dist/router.js": {"bytesInOutput": 2000},
+				"src/unused.ts": {"bytesInOutput": 0}
+			}
+		},
+		".micromachine/worker/chunk-ABC.js": {
+			"bytes": 80,
+			"inputs": {"src/routes.ts": {"bytesInOutput": 70}}
+		}
+	}
+}`
+
+func TestMetafileAnalyze(t *testing.T) {
+	metafile, err := ParseMetafile([]byte(testMetafile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := metafile.Analyze()
+
+	if len(report.Chunks) != 2 {
+		t.Fatalf("Expected source maps to be skipped, got %d chunks", len(report.Chunks))
+	}
+
+	chunk := report.Chunks[0]
+	if chunk.Path != ".micromachine/worker/index.js" {
+		t.Errorf("Expected the largest chunk first, got %s", chunk.Path)
+	}
+
+	if len(chunk.Modules) != 3 || chunk.Modules[0].Name != "node_modules/hono/dist/index.js" {
+		t.Errorf("Expected modules sorted by size without empty ones, got %v", chunk.Modules)
+	}
+
+	expected := []Contribution{{"hono", 4500}, {ProjectPackage, 150}}
+	if len(chunk.Packages) != len(expected) || chunk.Packages[0] != expected[0] || chunk.Packages[1] != expected[1] {
+		t.Errorf("Expected packages to be %v, got %v", expected, chunk.Packages)
+	}
+
+	if top := Top(chunk.Modules, 1); len(top) != 1 {
+		t.Errorf("Expected Top to keep 1 module, got %d", len(top))
+	}
+}
+
+func TestReportWriteTreemap(t *testing.T) {
+	metafile, err := ParseMetafile([]byte(testMetafile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := metafile.Analyze().WriteTreemap(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	html := out.String()
+	for _, expected := range []string{"<!DOCTYPE html>", `"name":"hono"`, `"name":"dist/router.js"`} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Expected treemap to contain %s", expected)
+		}
+	}
+}
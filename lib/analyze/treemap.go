@@ -0,0 +1,129 @@
+package analyze
+
+import (
+	"html/template"
+	"io"
+	"path"
+	"strings"
+)
+
+// TreemapNode is a rectangle of the treemap: a chunk, a package or a module.
+type TreemapNode struct {
+	Name     string         `json:"name"`
+	Bytes    int64          `json:"bytes"`
+	Children []*TreemapNode `json:"children,omitempty"`
+}
+
+// Tree nests the modules of every chunk under their package.
+func (r *Report) Tree() *TreemapNode {
+	root := &TreemapNode{Name: "bundle"}
+
+	for _, chunk := range r.Chunks {
+		chunkNode := &TreemapNode{Name: chunk.Path, Bytes: chunk.Bytes}
+		packages := map[string]*TreemapNode{}
+
+		for _, pkg := range chunk.Packages {
+			node := &TreemapNode{Name: pkg.Name, Bytes: pkg.Bytes}
+			packages[pkg.Name] = node
+			chunkNode.Children = append(chunkNode.Children, node)
+		}
+
+		for _, module := range chunk.Modules {
+			pkg := packages[PackageName(module.Name)]
+			name := module.Name
+			if i := strings.LastIndex(name, "node_modules/"+pkg.Name+"/"); i >= 0 {
+				name = name[i+len("node_modules/"+pkg.Name+"/"):]
+			}
+			pkg.Children = append(pkg.Children, &TreemapNode{Name: path.Clean(name), Bytes: module.Bytes})
+		}
+
+		root.Bytes += chunk.Bytes
+		root.Children = append(root.Children, chunkNode)
+	}
+
+	return root
+}
+
+// WriteTreemap renders the report as a self-contained HTML treemap.
+func (r *Report) WriteTreemap(w io.Writer) error {
+	return treemapTemplate.Execute(w, r.Tree())
+}
+
+var treemapTemplate = template.Must(template.New("treemap").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>micromachine bundle analysis</title>
+<style>
+	html, body { margin: 0; height: 100%; font: 12px system-ui, sans-serif; }
+	header { padding: 8px 12px; border-bottom: 1px solid #ddd; }
+	#treemap { position: absolute; top: 36px; left: 0; right: 0; bottom: 0; }
+	.node { position: absolute; box-sizing: border-box; overflow: hidden; border: 1px solid #fff; }
+	.node > span { display: block; padding: 2px 4px; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+</style>
+</head>
+<body>
+<header id="title"></header>
+<div id="treemap"></div>
+<script>
+const tree = {{.}};
+
+function formatBytes(size) {
+	const units = ["B", "KiB", "MiB", "GiB"];
+	let i = 0;
+	while (size >= 1024 && i < units.length - 1) { size /= 1024; i++; }
+	return (i === 0 ? size : size.toFixed(1)) + " " + units[i];
+}
+
+function color(depth, index) {
+	return "hsl(" + ((index * 47 + depth * 90) % 360) + ", 55%, " + (85 - depth * 10) + "%)";
+}
+
+// layout splits a rectangle between the children of a node, alternating the
+// direction with the depth (slice-and-dice).
+function layout(parent, node, x, y, w, h, depth, index) {
+	const el = document.createElement("div");
+	el.className = "node";
+	el.style.left = x + "px";
+	el.style.top = y + "px";
+	el.style.width = w + "px";
+	el.style.height = h + "px";
+	el.style.background = color(depth, index);
+	el.title = node.name + " (" + formatBytes(node.bytes) + ")";
+
+	const label = document.createElement("span");
+	label.textContent = node.name + " " + formatBytes(node.bytes);
+	el.appendChild(label);
+	parent.appendChild(el);
+
+	const children = (node.children || []).filter(c => c.bytes > 0);
+	const total = children.reduce((sum, c) => sum + c.bytes, 0);
+	if (total === 0 || w < 8 || h < 24) return;
+
+	const top = 18, inner = { w: w - 2, h: h - top - 2 };
+	let offset = 0;
+	children.forEach((child, i) => {
+		const share = child.bytes / total;
+		if (depth % 2 === 0) {
+			layout(el, child, offset, top, inner.w * share, inner.h, depth + 1, i);
+			offset += inner.w * share;
+		} else {
+			layout(el, child, 0, top + offset, inner.w, inner.h * share, depth + 1, i);
+			offset += inner.h * share;
+		}
+	});
+}
+
+function render() {
+	const container = document.getElementById("treemap");
+	container.replaceChildren();
+	layout(container, tree, 0, 0, container.clientWidth, container.clientHeight, 0, 0);
+}
+
+document.getElementById("title").textContent = "Bundle analysis: " + formatBytes(tree.bytes);
+window.addEventListener("resize", render);
+render();
+</script>
+</body>
+</html>
+`))
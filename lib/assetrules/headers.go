@@ -0,0 +1,145 @@
+package assetrules
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Limits of the `_headers` file.
+const (
+	MaxHeaderRules      = 100
+	MaxHeaderLineLength = 2000
+)
+
+var headerNamePattern = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// Header is a header set by a `_headers` rule.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// HeaderRule is a URL pattern of a `_headers` file with the headers it sets
+// and the ones it detaches (`! Name`) from less specific rules.
+type HeaderRule struct {
+	Line    int
+	Pattern string
+	Headers []Header
+	Detach  []string
+
+	pattern *pattern
+}
+
+// Match reports whether the rule applies to pathname.
+func (r *HeaderRule) Match(pathname string) bool {
+	_, ok := r.pattern.match(pathname)
+	return ok
+}
+
+// ParseHeaders parses a `_headers` file. Invalid lines are reported as
+// problems and left out of the returned rules.
+func ParseHeaders(r io.Reader) ([]HeaderRule, []Problem, error) {
+	var rules []HeaderRule
+	var problems []Problem
+	var current *HeaderRule
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := scanner.Text()
+		text := strings.TrimSpace(raw)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if len(raw) > MaxHeaderLineLength {
+			problems = append(problems, errorf(line, "line is longer than %d characters", MaxHeaderLineLength))
+			continue
+		}
+
+		// Unindented lines start a new rule, indented ones add headers to it.
+		if raw[0] != ' ' && raw[0] != '\t' {
+			rule, err := parseHeaderPattern(line, text)
+			if err != nil {
+				problems = append(problems, errorf(line, "%v", err))
+				current = nil
+				continue
+			}
+
+			rules = append(rules, *rule)
+			current = &rules[len(rules)-1]
+			if len(rules) == MaxHeaderRules+1 {
+				problems = append(problems, errorf(line, "more than %d header rules", MaxHeaderRules))
+			}
+			continue
+		}
+
+		if current == nil {
+			problems = append(problems, errorf(line, "header `%s` is not preceded by a URL pattern", text))
+			continue
+		}
+
+		if name, found := strings.CutPrefix(text, "!"); found {
+			name = strings.TrimSpace(name)
+			if !headerNamePattern.MatchString(name) {
+				problems = append(problems, errorf(line, "invalid header name `%s`", name))
+				continue
+			}
+			current.Detach = append(current.Detach, name)
+			continue
+		}
+
+		name, value, found := strings.Cut(text, ":")
+		if !found {
+			problems = append(problems, errorf(line, "expected `Name: value`, got `%s`", text))
+			continue
+		}
+
+		name = strings.TrimSpace(name)
+		if !headerNamePattern.MatchString(name) {
+			problems = append(problems, errorf(line, "invalid header name `%s`", name))
+			continue
+		}
+
+		current.Headers = append(current.Headers, Header{Name: name, Value: strings.TrimSpace(value)})
+	}
+
+	for _, rule := range rules {
+		if len(rule.Headers) == 0 && len(rule.Detach) == 0 {
+			problems = append(problems, warnf(rule.Line, "`%s` sets no headers", rule.Pattern))
+		}
+	}
+
+	return rules, problems, scanner.Err()
+}
+
+func parseHeaderPattern(line int, text string) (*HeaderRule, error) {
+	if strings.ContainsAny(text, " \t") {
+		return nil, fmt.Errorf("URL pattern `%s` cannot contain spaces, indent header lines", text)
+	}
+
+	// Absolute patterns such as `https://:project.example.com/*` also match
+	// on the host; only the path is matched against assets.
+	path := text
+	if strings.HasPrefix(text, "https://") || strings.HasPrefix(text, "http://") {
+		_, rest, _ := strings.Cut(text, "://")
+		i := strings.Index(rest, "/")
+		if i < 0 {
+			return nil, fmt.Errorf("URL pattern `%s` has no path", text)
+		}
+		path = rest[i:]
+	} else if !strings.HasPrefix(text, "/") {
+		return nil, fmt.Errorf("URL pattern `%s` must start with `/` or `https://`", text)
+	}
+
+	p, err := compilePattern(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HeaderRule{Line: line, Pattern: text, pattern: p}, nil
+}
@@ -0,0 +1,115 @@
+package assetrules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseHeaders(t *testing.T) {
+	content := `# https://developers.cloudflare.com/workers/static-assets/headers
+/_next/static/*
+  Cache-Control: public,max-age=31536000,immutable
+/secure/:page
+  X-Frame-Options: DENY
+  ! Cache-Control
+https://:project.example.com/*
+  X-Robots-Tag: noindex
+  Missing colon
+  Bad Name: value
+/empty
+not-a-path
+`
+
+	rules, problems, err := ParseHeaders(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rules) != 4 {
+		t.Fatalf("Expected 4 rules, got %d", len(rules))
+	}
+
+	if rules[1].Detach[0] != "Cache-Control" || rules[1].Headers[0] != (Header{"X-Frame-Options", "DENY"}) {
+		t.Errorf("Unexpected headers of %s: %v, detach %v", rules[1].Pattern, rules[1].Headers, rules[1].Detach)
+	}
+
+	expected := []struct {
+		line     int
+		severity string
+	}{
+		{9, SeverityError},
+		{10, SeverityError},
+		{12, SeverityError},
+		{11, SeverityWarning},
+	}
+
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %v", len(expected), problems)
+	}
+	for i, problem := range problems {
+		if problem.Line != expected[i].line || problem.Severity != expected[i].severity {
+			t.Errorf("Expected %s on line %d, got %v", expected[i].severity, expected[i].line, problem)
+		}
+	}
+}
+
+func TestHeaderRuleMatch(t *testing.T) {
+	rules, _, err := ParseHeaders(strings.NewReader("/_next/static/*\n  A: 1\nhttps://:project.example.com/docs/:page\n  B: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		rule    int
+		path    string
+		matches bool
+	}{
+		{0, "/_next/static/chunks/main.js", true},
+		{0, "/_next/image", false},
+		{1, "/docs/intro", true},
+		{1, "/docs/intro/more", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := rules[tt.rule].Match(tt.path); got != tt.matches {
+				t.Errorf("Expected %s matching %s to be %v, got %v", rules[tt.rule].Pattern, tt.path, tt.matches, got)
+			}
+		})
+	}
+}
+
+func TestRulesUnmatched(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		HeadersFile:   "/_next/static/*\n  A: 1\n/about\n  B: 2\n/RSC/*\n  C: 3\n",
+		RedirectsFile: "/spa /index.html 200\n/gone /missing.html 200\n/old /new 301\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules, problems, err := Load(dir)
+	if err != nil || len(problems) != 0 {
+		t.Fatalf("Expected files to load without problems, got %v (%v)", problems, err)
+	}
+
+	problems = rules.Unmatched([]string{"/_next/static/main.js", "/about.html", "/index.html"})
+
+	if len(problems) != 2 {
+		t.Fatalf("Expected 2 unmatched rules, got %v", problems)
+	}
+
+	if problems[0].Path != filepath.Join(dir, HeadersFile) || problems[0].Line != 5 {
+		t.Errorf("Expected `/RSC/*` to match no asset, got %v", problems[0])
+	}
+
+	if problems[1].Path != filepath.Join(dir, RedirectsFile) || problems[1].Line != 2 {
+		t.Errorf("Expected `/gone` to rewrite to a missing asset, got %v", problems[1])
+	}
+}
@@ -0,0 +1,83 @@
+package assetrules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var placeholderPattern = regexp.MustCompile(`:[A-Za-z]\w*`)
+
+// pattern is a URL pattern of a `_headers` or `_redirects` rule. A `*` splat
+// matches anything and a `:name` placeholder matches a single path segment.
+type pattern struct {
+	raw          string
+	re           *regexp.Regexp
+	placeholders []string
+}
+
+func compilePattern(raw string) (*pattern, error) {
+	if strings.Count(raw, "*") > 1 {
+		return nil, fmt.Errorf("`%s` has more than one splat", raw)
+	}
+
+	p := &pattern{raw: raw}
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	rest := raw
+	for rest != "" {
+		loc := placeholderPattern.FindStringIndex(rest)
+		star := strings.Index(rest, "*")
+
+		switch {
+		case star >= 0 && (loc == nil || star < loc[0]):
+			expr.WriteString(regexp.QuoteMeta(rest[:star]))
+			expr.WriteString("(?P<splat>.*)")
+			p.placeholders = append(p.placeholders, "splat")
+			rest = rest[star+1:]
+		case loc != nil:
+			name := rest[loc[0]+1 : loc[1]]
+			expr.WriteString(regexp.QuoteMeta(rest[:loc[0]]))
+			expr.WriteString("(?P<" + name + ">[^/]+)")
+			p.placeholders = append(p.placeholders, name)
+			rest = rest[loc[1]:]
+		default:
+			expr.WriteString(regexp.QuoteMeta(rest))
+			rest = ""
+		}
+	}
+
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("`%s` is not a valid pattern: %w", raw, err)
+	}
+	p.re = re
+
+	return p, nil
+}
+
+// dynamic reports whether the pattern has a splat or a placeholder.
+func (p *pattern) dynamic() bool {
+	return len(p.placeholders) > 0
+}
+
+// match returns the values of the placeholders of pathname, keyed by name,
+// when it matches the pattern.
+func (p *pattern) match(pathname string) (map[string]string, bool) {
+	m := p.re.FindStringSubmatch(pathname)
+	if m == nil {
+		return nil, false
+	}
+
+	values := map[string]string{}
+	for i, name := range p.re.SubexpNames() {
+		if name != "" {
+			values[name] = m[i]
+		}
+	}
+
+	return values, true
+}
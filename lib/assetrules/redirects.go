@@ -0,0 +1,150 @@
+package assetrules
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Limits of the `_redirects` file.
+const (
+	MaxStaticRedirects  = 2000
+	MaxDynamicRedirects = 100
+	MaxRedirectLength   = 1000
+)
+
+var redirectStatuses = []int{200, 301, 302, 303, 307, 308}
+
+// Redirect is a rule of a `_redirects` file.
+type Redirect struct {
+	Line        int
+	Source      string
+	Destination string
+	Status      int
+
+	source *pattern
+}
+
+// Dynamic reports whether the source has a splat or a placeholder.
+func (r *Redirect) Dynamic() bool {
+	return r.source.dynamic()
+}
+
+// Match returns the destination of pathname, with the splat and placeholders
+// substituted, when the rule applies to it.
+func (r *Redirect) Match(pathname string) (string, bool) {
+	values, ok := r.source.match(pathname)
+	if !ok {
+		return "", false
+	}
+
+	destination := r.Destination
+	for name, value := range values {
+		destination = strings.ReplaceAll(destination, ":"+name, value)
+	}
+
+	return destination, true
+}
+
+// ParseRedirects parses a `_redirects` file. Invalid rules are reported as
+// problems and left out of the returned rules.
+func ParseRedirects(r io.Reader) ([]Redirect, []Problem, error) {
+	var redirects []Redirect
+	var problems []Problem
+	static, dynamic := 0, 0
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if len(text) > MaxRedirectLength {
+			problems = append(problems, errorf(line, "rule is longer than %d characters", MaxRedirectLength))
+			continue
+		}
+
+		redirect, err := parseRedirect(line, text)
+		if err != nil {
+			problems = append(problems, errorf(line, "%v", err))
+			continue
+		}
+
+		if redirect.Dynamic() {
+			dynamic++
+			if dynamic == MaxDynamicRedirects+1 {
+				problems = append(problems, errorf(line, "more than %d dynamic redirects", MaxDynamicRedirects))
+			}
+		} else {
+			static++
+			if static == MaxStaticRedirects+1 {
+				problems = append(problems, errorf(line, "more than %d static redirects", MaxStaticRedirects))
+			}
+		}
+
+		redirects = append(redirects, *redirect)
+	}
+
+	return redirects, problems, scanner.Err()
+}
+
+func parseRedirect(line int, text string) (*Redirect, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, fmt.Errorf("expected `source destination [status]`, got %d field(s)", len(fields))
+	}
+
+	redirect := &Redirect{
+		Line:        line,
+		Source:      fields[0],
+		Destination: fields[1],
+		Status:      302,
+	}
+
+	if len(fields) == 3 {
+		status, err := strconv.Atoi(fields[2])
+		if err != nil || !slices.Contains(redirectStatuses, status) {
+			return nil, fmt.Errorf("invalid status `%s`, expected one of 200, 301, 302, 303, 307 or 308", fields[2])
+		}
+		redirect.Status = status
+	}
+
+	if !strings.HasPrefix(redirect.Source, "/") {
+		return nil, fmt.Errorf("source `%s` must be a path starting with `/`", redirect.Source)
+	}
+
+	if strings.Contains(redirect.Source, "?") {
+		return nil, fmt.Errorf("source `%s` cannot match query parameters", redirect.Source)
+	}
+
+	source, err := compilePattern(redirect.Source)
+	if err != nil {
+		return nil, err
+	}
+	redirect.source = source
+
+	if !strings.HasPrefix(redirect.Destination, "/") {
+		u, err := url.Parse(redirect.Destination)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("destination `%s` must be a path or an absolute URL", redirect.Destination)
+		}
+		if redirect.Status == 200 {
+			return nil, fmt.Errorf("status 200 rewrites cannot point to an absolute URL")
+		}
+	}
+
+	for _, name := range placeholderPattern.FindAllString(redirect.Destination, -1) {
+		if !slices.Contains(source.placeholders, name[1:]) {
+			return nil, fmt.Errorf("placeholder `%s` of the destination is not in the source", name)
+		}
+	}
+
+	return redirect, nil
+}
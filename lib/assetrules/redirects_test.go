@@ -0,0 +1,100 @@
+package assetrules
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseRedirects(t *testing.T) {
+	content := `# Redirects
+/home / 301
+/blog/* /articles/:splat
+/users/:id /profile/:id 308
+/docs https://docs.example.com
+/spa /index.html 200
+
+/broken
+/teapot / 418
+relative /
+/a/:id /b/:slug
+/rewrite https://example.com 200
+`
+
+	redirects, problems, err := ParseRedirects(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(redirects) != 5 {
+		t.Errorf("Expected 5 valid redirects, got %d", len(redirects))
+	}
+
+	expectedLines := []int{8, 9, 10, 11, 12}
+	if len(problems) != len(expectedLines) {
+		t.Fatalf("Expected %d problems, got %v", len(expectedLines), problems)
+	}
+	for i, problem := range problems {
+		if problem.Line != expectedLines[i] || problem.Severity != SeverityError {
+			t.Errorf("Expected an error on line %d, got %v", expectedLines[i], problem)
+		}
+	}
+
+	if redirects[1].Status != 302 {
+		t.Errorf("Expected the default status to be 302, got %d", redirects[1].Status)
+	}
+}
+
+func TestRedirectMatch(t *testing.T) {
+	content := "/blog/* /articles/:splat 301\n/users/:id/posts/:post /u/:id/:post\n/exact /other\n"
+
+	redirects, _, err := ParseRedirects(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		rule        int
+		path        string
+		destination string
+		matches     bool
+	}{
+		{"Splat", 0, "/blog/2024/hello", "/articles/2024/hello", true},
+		{"Splat does not match the parent", 0, "/blogs", "", false},
+		{"Placeholders", 1, "/users/42/posts/7", "/u/42/7", true},
+		{"Placeholders match a single segment", 1, "/users/4/2/posts/7", "", false},
+		{"Static", 2, "/exact", "/other", true},
+		{"Static is exact", 2, "/exact/", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destination, ok := redirects[tt.rule].Match(tt.path)
+			if ok != tt.matches || destination != tt.destination {
+				t.Errorf("Expected %s to give (%q, %v), got (%q, %v)", tt.path, tt.destination, tt.matches, destination, ok)
+			}
+		})
+	}
+}
+
+func TestParseRedirectsLimits(t *testing.T) {
+	var content strings.Builder
+	for i := range MaxDynamicRedirects + 1 {
+		fmt.Fprintf(&content, "/dynamic-%d/* /:splat\n", i)
+	}
+	fmt.Fprintf(&content, "/long /%s\n", strings.Repeat("a", MaxRedirectLength))
+
+	_, problems, err := ParseRedirects(strings.NewReader(content.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(problems) != 2 {
+		t.Fatalf("Expected the dynamic and length limits to be reported, got %v", problems)
+	}
+
+	if problems[0].Line != MaxDynamicRedirects+1 {
+		t.Errorf("Expected the dynamic limit to be reported on line %d, got %d", MaxDynamicRedirects+1, problems[0].Line)
+	}
+}
@@ -0,0 +1,161 @@
+// Package assetrules parses the `_headers` and `_redirects` files of a Workers
+// static-assets directory.
+package assetrules
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	HeadersFile   = "_headers"
+	RedirectsFile = "_redirects"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is an issue found in a `_headers` or `_redirects` file.
+type Problem struct {
+	Severity string
+	Path     string
+	Line     int
+	Message  string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", p.Path, p.Line, p.Severity, p.Message)
+}
+
+func errorf(line int, format string, args ...any) Problem {
+	return Problem{Severity: SeverityError, Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+func warnf(line int, format string, args ...any) Problem {
+	return Problem{Severity: SeverityWarning, Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+// Rules are the parsed `_headers` and `_redirects` of an assets directory.
+type Rules struct {
+	HeadersPath   string
+	Headers       []HeaderRule
+	RedirectsPath string
+	Redirects     []Redirect
+}
+
+// Load parses the `_headers` and `_redirects` files of dir, when they exist.
+func Load(dir string) (*Rules, []Problem, error) {
+	rules := &Rules{}
+	var problems []Problem
+
+	headersPath := filepath.Join(dir, HeadersFile)
+	found, err := parseFile(headersPath, &problems, func(r io.Reader) ([]Problem, error) {
+		headers, problems, err := ParseHeaders(r)
+		rules.Headers = headers
+		return problems, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if found {
+		rules.HeadersPath = headersPath
+	}
+
+	redirectsPath := filepath.Join(dir, RedirectsFile)
+	found, err = parseFile(redirectsPath, &problems, func(r io.Reader) ([]Problem, error) {
+		redirects, problems, err := ParseRedirects(r)
+		rules.Redirects = redirects
+		return problems, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if found {
+		rules.RedirectsPath = redirectsPath
+	}
+
+	return rules, problems, nil
+}
+
+func parseFile(path string, problems *[]Problem, parse func(r io.Reader) ([]Problem, error)) (bool, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	found, err := parse(file)
+	if err != nil {
+		return true, fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	for _, problem := range found {
+		problem.Path = path
+		*problems = append(*problems, problem)
+	}
+
+	return true, nil
+}
+
+// Unmatched warns about the header rules that apply to none of the given
+// asset pathnames, and about the rewrites (status 200) to a path that is not
+// an asset. An asset is also reachable without its `.html` extension or its
+// `index.html` file name.
+func (r *Rules) Unmatched(assets []string) []Problem {
+	var pathnames []string
+	for _, asset := range assets {
+		pathnames = append(pathnames, asset)
+		if trimmed, found := strings.CutSuffix(asset, "index.html"); found {
+			pathnames = append(pathnames, trimmed)
+		}
+		if trimmed, found := strings.CutSuffix(asset, ".html"); found {
+			pathnames = append(pathnames, trimmed)
+		}
+	}
+
+	var problems []Problem
+
+	for _, rule := range r.Headers {
+		if !matchesAny(rule.Match, pathnames) {
+			problem := warnf(rule.Line, "`%s` matches no asset", rule.Pattern)
+			problem.Path = r.HeadersPath
+			problems = append(problems, problem)
+		}
+	}
+
+	for _, redirect := range r.Redirects {
+		if redirect.Status != 200 || redirect.Dynamic() {
+			continue
+		}
+
+		destination, _, _ := strings.Cut(redirect.Destination, "?")
+		if !matchesAny(func(pathname string) bool { return pathname == destination }, pathnames) {
+			problem := warnf(redirect.Line, "rewrite destination `%s` is not an asset", redirect.Destination)
+			problem.Path = r.RedirectsPath
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems
+}
+
+func matchesAny(match func(string) bool, pathnames []string) bool {
+	for _, pathname := range pathnames {
+		if match(pathname) {
+			return true
+		}
+	}
+
+	return false
+}
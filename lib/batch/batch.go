@@ -0,0 +1,222 @@
+// Package batch builds several projects of a repository concurrently, keeping
+// the output of each one recognisable.
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"micromachine.dev/cmd-utils/lib/bundler"
+	"micromachine.dev/cmd-utils/lib/frameworks"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// skippedDirs are never searched for projects.
+var skippedDirs = []string{"node_modules"}
+
+// Project is a directory holding a wrangler configuration.
+type Project struct {
+	// Name is the directory relative to the search root, e.g. `apps/web`.
+	Name string
+	Dir  string
+}
+
+// Result is the outcome of the build of a project.
+type Result struct {
+	Project
+	Duration time.Duration
+	// ExitCode is the exit code of the build command, or -1 when it could
+	// not be started.
+	ExitCode int
+	Err      error
+}
+
+// FindProjects returns the projects in root. Without patterns, every
+// directory of root with a wrangler file is a project, except for hidden and
+// `node_modules` directories, the directories nested in a project other than
+// root, and the output directories of a worker at root, which hold the
+// wrangler files generated by framework builds. With patterns, the
+// directories matching any of the globs, relative to root, that have a
+// wrangler file are the projects.
+func FindProjects(root string, patterns []string) ([]Project, error) {
+	var dirs []string
+
+	if len(patterns) > 0 {
+		for _, pattern := range patterns {
+			matches, err := filepath.Glob(filepath.Join(root, pattern))
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern `%s`: %w", pattern, err)
+			}
+			for _, match := range matches {
+				if info, err := os.Stat(match); err == nil && info.IsDir() && hasWranglerFile(match) {
+					dirs = append(dirs, match)
+				}
+			}
+		}
+	} else {
+		var outputDirs []string
+		if hasWranglerFile(root) {
+			outputDirs = rootOutputDirs(root)
+		}
+
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+
+			if path != root && (strings.HasPrefix(d.Name(), ".") || slices.Contains(skippedDirs, d.Name())) {
+				return filepath.SkipDir
+			}
+
+			if rel, err := filepath.Rel(root, path); err == nil && slices.Contains(outputDirs, rel) {
+				return filepath.SkipDir
+			}
+
+			if hasWranglerFile(path) {
+				dirs = append(dirs, path)
+				// A worker at the root does not hide the projects of the
+				// repository.
+				if path != root {
+					return filepath.SkipDir
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slices.Sort(dirs)
+	dirs = slices.Compact(dirs)
+
+	projects := make([]Project, 0, len(dirs))
+	for _, dir := range dirs {
+		name, err := filepath.Rel(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		if name == "." {
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				return nil, err
+			}
+			name = filepath.Base(abs)
+		}
+
+		projects = append(projects, Project{Name: filepath.ToSlash(name), Dir: dir})
+	}
+
+	return projects, nil
+}
+
+// rootOutputDirs returns the directories the build of the worker at root
+// writes to, e.g. `dist/server`, which hold the wrangler files generated by
+// its framework rather than projects of their own.
+func rootOutputDirs(root string) []string {
+	conf, err := utils.DetectWranglerFile[utils.WranglerConfig](&root)
+	if err != nil {
+		return nil
+	}
+
+	bundle := &bundler.Bundle{RootDir: root, ModulePath: conf.Main, WranglerConfig: conf}
+	if conf.Assets != nil {
+		bundle.AssetPath = conf.Assets.Directory
+	}
+	bundle.Framework, _ = frameworks.Match(root)
+
+	return bundle.OutputDirs()
+}
+
+func hasWranglerFile(dir string) bool {
+	_, err := utils.FindWranglerFile(&dir)
+	return err == nil
+}
+
+// Runner runs a command for every project, at most Parallel at once.
+type Runner struct {
+	Parallel int
+	// Output receives the output of every command, each line prefixed with
+	// the name of its project.
+	Output io.Writer
+	// Command returns the command building a project.
+	Command func(project Project) *exec.Cmd
+}
+
+// Run builds every project and returns the results in the order of projects.
+// A failing build does not stop the others.
+func (r *Runner) Run(projects []Project) []Result {
+	results := make([]Result, len(projects))
+	parallel := max(r.Parallel, 1)
+
+	width := 0
+	for _, project := range projects {
+		width = max(width, len(project.Name))
+	}
+
+	var outputMu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, parallel)
+
+	for i, project := range projects {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			writer := &prefixWriter{
+				mu:     &outputMu,
+				out:    r.Output,
+				prefix: []byte(fmt.Sprintf("%-*s │ ", width, project.Name)),
+			}
+
+			results[i] = r.run(project, writer)
+			_ = writer.Flush()
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+func (r *Runner) run(project Project, output io.Writer) Result {
+	result := Result{Project: project}
+
+	cmd := r.Command(project)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	start := time.Now()
+	err := cmd.Run()
+	result.Duration = time.Since(start)
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+		result.Err = err
+	default:
+		result.ExitCode = -1
+		result.Err = err
+	}
+
+	return result
+}
@@ -0,0 +1,164 @@
+package batch
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeRepo(t *testing.T, files ...string) string {
+	dir := t.TempDir()
+
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(`{"name": "worker"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestFindProjects(t *testing.T) {
+	repo := []string{
+		"apps/web/wrangler.jsonc",
+		"apps/web/build/server/wrangler.json",
+		"apps/api/wrangler.toml",
+		"apps/docs/package.json",
+		"workers/cron/wrangler.json",
+		"node_modules/some-package/wrangler.json",
+		".wrangler/tmp/wrangler.json",
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		expected []string
+	}{
+		{"Every project", nil, []string{"apps/api", "apps/web", "workers/cron"}},
+		{"Glob", []string{"apps/*"}, []string{"apps/api", "apps/web"}},
+		{"Several globs", []string{"workers/*", "apps/web"}, []string{"apps/web", "workers/cron"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeRepo(t, repo...)
+
+			projects, err := FindProjects(dir, tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, project := range projects {
+				names = append(names, project.Name)
+			}
+
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestFindProjectsWithRootWorker(t *testing.T) {
+	dir := writeRepo(t, "wrangler.toml", "apps/web/wrangler.jsonc", "apps/api/wrangler.toml")
+
+	projects, err := FindProjects(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, project := range projects {
+		names = append(names, project.Name)
+	}
+
+	expected := []string{filepath.Base(dir), "apps/api", "apps/web"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+	if len(projects) > 0 && projects[0].Dir != dir {
+		t.Errorf("Expected the root project in %s, got %s", dir, projects[0].Dir)
+	}
+}
+
+func TestFindProjectsSkipsRootOutputs(t *testing.T) {
+	dir := writeRepo(t, "dist/server/wrangler.json", "public/docs/wrangler.json", "apps/api/wrangler.jsonc")
+	conf := `{"name": "root", "main": "src/index.ts", "assets": {"directory": "public"}}`
+	if err := os.WriteFile(filepath.Join(dir, "wrangler.jsonc"), []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	projects, err := FindProjects(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, project := range projects {
+		names = append(names, project.Name)
+	}
+
+	expected := []string{filepath.Base(dir), "apps/api"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestRunnerRun(t *testing.T) {
+	projects := []Project{
+		{Name: "ok", Dir: "."},
+		{Name: "broken", Dir: "."},
+		{Name: "over-budget", Dir: "."},
+	}
+
+	scripts := map[string]string{
+		"ok":          "echo building; echo done",
+		"broken":      "echo failing >&2; exit 1",
+		"over-budget": "printf 'too large'; exit 3",
+	}
+
+	var output bytes.Buffer
+	runner := &Runner{
+		Parallel: 2,
+		Output:   &output,
+		Command: func(project Project) *exec.Cmd {
+			return exec.Command("sh", "-c", scripts[project.Name])
+		},
+	}
+
+	results := runner.Run(projects)
+
+	expectedCodes := []int{0, 1, 3}
+	for i, result := range results {
+		if result.Name != projects[i].Name {
+			t.Errorf("Expected result %d to be %s, got %s", i, projects[i].Name, result.Name)
+		}
+		if result.ExitCode != expectedCodes[i] {
+			t.Errorf("Expected %s to exit with %d, got %d", result.Name, expectedCodes[i], result.ExitCode)
+		}
+		if (result.Err != nil) != (expectedCodes[i] != 0) {
+			t.Errorf("Unexpected error for %s: %v", result.Name, result.Err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	slices.Sort(lines)
+	expected := []string{
+		"broken      │ failing",
+		"ok          │ building",
+		"ok          │ done",
+		"over-budget │ too large",
+	}
+	if !slices.Equal(lines, expected) {
+		t.Errorf("Expected output %q, got %q", expected, lines)
+	}
+}
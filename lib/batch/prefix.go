@@ -0,0 +1,53 @@
+package batch
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// prefixWriter writes every line written to it to out, preceded by prefix.
+// Lines of writers sharing the same mutex are never interleaved.
+type prefixWriter struct {
+	mu      *sync.Mutex
+	out     io.Writer
+	prefix  []byte
+	pending []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+
+		if err := w.writeLine(w.pending[:i+1]); err != nil {
+			return 0, err
+		}
+		w.pending = w.pending[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the last line when it does not end with a newline.
+func (w *prefixWriter) Flush() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+
+	line := append(w.pending, '\n')
+	w.pending = nil
+	return w.writeLine(line)
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err := w.out.Write(append(append([]byte{}, w.prefix...), line...))
+	return err
+}
@@ -0,0 +1,39 @@
+package batch
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		writes   []string
+		expected string
+	}{
+		{"Whole lines", []string{"one\ntwo\n"}, "[a] one\n[a] two\n"},
+		{"Split lines", []string{"o", "ne\ntw", "o\n"}, "[a] one\n[a] two\n"},
+		{"Unterminated line", []string{"one\ntwo"}, "[a] one\n[a] two\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			writer := &prefixWriter{mu: &sync.Mutex{}, out: &out, prefix: []byte("[a] ")}
+
+			for _, write := range tt.writes {
+				if _, err := writer.Write([]byte(write)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writer.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			if out.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, out.String())
+			}
+		})
+	}
+}
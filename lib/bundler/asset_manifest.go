@@ -0,0 +1,131 @@
+package bundler
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/zeebo/blake3"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// assetManifestIgnore are files of the assets directory that configure the
+// platform rather than being served, and that wrangler does not upload.
+var assetManifestIgnore = []string{"_headers", "_redirects", ".assetsignore"}
+
+// AssetManifestEntry describes an uploaded asset.
+type AssetManifestEntry struct {
+	Hash        string `json:"hash"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType"`
+}
+
+// AssetManifest maps the pathname of every asset, e.g. `/images/logo.png`, to
+// its entry, as sent when creating a Workers static-assets upload session.
+type AssetManifest map[string]AssetManifestEntry
+
+// GetAssetManifestPath returns the path, relative to the root directory, of
+// the manifest of the copied assets.
+func (b *Bundle) GetAssetManifestPath() string {
+	return filepath.Join(b.GetOutputDir(), "assets-manifest.json")
+}
+
+// add records the asset at rel, relative to the assets directory.
+func (m AssetManifest) add(rel string, data []byte) {
+	pathname := "/" + filepath.ToSlash(rel)
+	if slices.Contains(assetManifestIgnore, path.Base(pathname)) {
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(pathname))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	m[pathname] = AssetManifestEntry{
+		Hash:        hashAsset(pathname, data),
+		Size:        int64(len(data)),
+		ContentType: contentType,
+	}
+}
+
+// hashAsset hashes the base64 content of an asset followed by its extension
+// with BLAKE3 and keeps the first 32 hex characters, as wrangler does, so that
+// assets it already uploaded are deduplicated.
+func hashAsset(pathname string, data []byte) string {
+	sum := blake3.Sum256([]byte(base64.StdEncoding.EncodeToString(data) + strings.TrimPrefix(path.Ext(pathname), ".")))
+	return hex.EncodeToString(sum[:])[:32]
+}
+
+// ReadAssetManifest reads a manifest written by a previous build.
+func ReadAssetManifest(path string) (AssetManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest AssetManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("could not parse asset manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// Diff returns the sorted pathnames of the assets added, changed and removed
+// since the previous manifest.
+func (m AssetManifest) Diff(previous AssetManifest) (added, changed, removed []string) {
+	for pathname, entry := range m {
+		old, ok := previous[pathname]
+		switch {
+		case !ok:
+			added = append(added, pathname)
+		case old.Hash != entry.Hash:
+			changed = append(changed, pathname)
+		}
+	}
+
+	for pathname := range previous {
+		if _, ok := m[pathname]; !ok {
+			removed = append(removed, pathname)
+		}
+	}
+
+	slices.Sort(added)
+	slices.Sort(changed)
+	slices.Sort(removed)
+	return added, changed, removed
+}
+
+// writeAssetManifest writes the manifest and logs what changed since the
+// previous build.
+func (b *Bundle) writeAssetManifest(absDir string, manifest AssetManifest) error {
+	manifestPath := filepath.Join(absDir, b.GetAssetManifestPath())
+
+	if previous, err := ReadAssetManifest(manifestPath); err == nil {
+		added, changed, removed := manifest.Diff(previous)
+		utils.LogWithColor(utils.Muted, fmt.Sprintf("Assets: %d added, %d changed, %d removed since the previous build", len(added), len(changed), len(removed)))
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode asset manifest: %w", err)
+	}
+
+	err = os.WriteFile(manifestPath, append(data, '\n'), 0644)
+	if err != nil {
+		slog.Error(fmt.Sprintf("%v", err))
+		return fmt.Errorf("could not write asset manifest: %w", err)
+	}
+
+	utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Wrote `%s` (%d assets)", filepath.ToSlash(filepath.Clean(b.GetAssetManifestPath())), len(manifest)))
+	return nil
+}
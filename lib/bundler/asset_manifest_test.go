@@ -0,0 +1,103 @@
+package bundler
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHashAsset(t *testing.T) {
+	tests := []struct {
+		name     string
+		pathname string
+		data     string
+		expected string
+	}{
+		{
+			// The BLAKE3 hash of the empty input, the value wrangler writes for
+			// an empty file without an extension.
+			"Empty file without an extension",
+			"/LICENSE",
+			"",
+			"af1349b9f5f9a1a6a0404dea36dcc949",
+		},
+		{
+			"HTML file",
+			"/index.html",
+			"<h1>Hello</h1>\n",
+			"569c49c876d9d1d37936e494e70a80e8",
+		},
+		{
+			"Same content and extension in another directory",
+			"/blog/about.html",
+			"<h1>Hello</h1>\n",
+			"569c49c876d9d1d37936e494e70a80e8",
+		},
+		{
+			"CSS file",
+			"/styles/main.css",
+			"body { margin: 0; }\n",
+			"f26b40bbddbb5d96a41b1cc0881e0510",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hashAsset(tt.pathname, []byte(tt.data)); got != tt.expected {
+				t.Errorf("hashAsset() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAssetManifestDiff(t *testing.T) {
+	previous := AssetManifest{
+		"/index.html": {Hash: "a"},
+		"/main.css":   {Hash: "b"},
+		"/old.js":     {Hash: "c"},
+	}
+
+	tests := []struct {
+		name     string
+		manifest AssetManifest
+		added    []string
+		changed  []string
+		removed  []string
+	}{
+		{
+			"Unchanged",
+			AssetManifest{"/index.html": {Hash: "a"}, "/main.css": {Hash: "b"}, "/old.js": {Hash: "c"}},
+			nil,
+			nil,
+			nil,
+		},
+		{
+			"Added, changed and removed",
+			AssetManifest{"/index.html": {Hash: "a"}, "/main.css": {Hash: "d"}, "/new.js": {Hash: "e"}, "/app.js": {Hash: "f"}},
+			[]string{"/app.js", "/new.js"},
+			[]string{"/main.css"},
+			[]string{"/old.js"},
+		},
+		{
+			"Everything removed",
+			AssetManifest{},
+			nil,
+			nil,
+			[]string{"/index.html", "/main.css", "/old.js"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, changed, removed := tt.manifest.Diff(previous)
+			if !slices.Equal(added, tt.added) {
+				t.Errorf("added = %v, want %v", added, tt.added)
+			}
+			if !slices.Equal(changed, tt.changed) {
+				t.Errorf("changed = %v, want %v", changed, tt.changed)
+			}
+			if !slices.Equal(removed, tt.removed) {
+				t.Errorf("removed = %v, want %v", removed, tt.removed)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package bundler
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"micromachine.dev/cmd-utils/lib/assetrules"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// checkAssetRules validates the `_headers` and `_redirects` files of the
+// assets directory and warns about the rules that match none of the copied
+// assets.
+func (b *Bundle) checkAssetRules(absDir, dir string, manifest AssetManifest) error {
+	rules, problems, err := assetrules.Load(dir)
+	if err != nil {
+		slog.Error(fmt.Sprintf("%v", err))
+		return err
+	}
+
+	problems = append(problems, rules.Unmatched(slices.Sorted(maps.Keys(manifest)))...)
+
+	errors := 0
+	for _, problem := range problems {
+		if rel, err := filepath.Rel(absDir, problem.Path); err == nil {
+			problem.Path = rel
+		}
+
+		color := utils.Warning
+		if problem.Severity == assetrules.SeverityError {
+			color = utils.Fail
+			errors++
+		}
+		utils.LogWithColor(color, problem.String())
+	}
+
+	if errors > 0 {
+		return fmt.Errorf("found %d error(s) in `_headers` or `_redirects`", errors)
+	}
+
+	if rules.HeadersPath != "" || rules.RedirectsPath != "" {
+		utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Checked %d header rule(s) and %d redirect(s)", len(rules.Headers), len(rules.Redirects)))
+	}
+
+	return nil
+}
@@ -21,13 +21,39 @@ import (
 type Bundle struct {
 	RootDir             string
 	ModulePath          string
-	PackageManager      string
+	PackageManager      utils.PackageManager
 	AssetPath           string
 	BuildScript         string
-	Environment         string
 	WranglerConfig      *utils.WranglerConfig
 	BuildWranglerConfig *utils.NormalizedWranglerConfig
 	ShouldBundle        bool
+	// Environment is the wrangler environment whose `env.<name>` block is
+	// merged with the top-level configuration, empty for the top level.
+	Environment string
+	// NodeEnv is the `process.env.NODE_ENV` of the bundle, `production` when
+	// empty.
+	NodeEnv string
+	// Analyze writes esbuild's metafile to GetMetafilePath when bundling.
+	Analyze bool
+	// Framework builds the project and locates its output.
+	Framework FrameworkAdapter
+	// CommandEnv are `KEY=value` variables added to the environment of the
+	// commands run by RunCommand.
+	CommandEnv []string
+	// Offline forbids downloads during the build, e.g. of unenv.
+	Offline bool
+	// Frozen refuses the installs that would change the lockfile, see
+	// AddDevDependencies.
+	Frozen bool
+	// Workspace is the monorepo workspace the project belongs to, if any. Build
+	// scripts then run from its root through the package manager's filter.
+	Workspace *utils.Workspace
+	// InjectVitePlugin builds vite projects whose config lacks
+	// `@cloudflare/vite-plugin` with a generated config adding it.
+	InjectVitePlugin bool
+	// SizeBudget, when set, makes Pack fail with ErrSizeBudgetExceeded when
+	// the compressed worker is too large.
+	SizeBudget *utils.SizeBudget
 }
 
 func (b *Bundle) Pack() error {
@@ -44,128 +70,25 @@ func (b *Bundle) Pack() error {
 		return fmt.Errorf("could not create output directory: %w", err)
 	}
 
-	shouldBundle := utils.IsOpenNext(b.WranglerConfig) || b.ShouldBundle
-
-	if b.BuildWranglerConfig != nil {
-		shouldBundle = !b.BuildWranglerConfig.NoBundle
+	modulePath, err := b.resolveModulePath()
+	if err != nil {
+		return err
 	}
 
-	// Get the module path
-	modulePath := b.ModulePath
-	if b.BuildWranglerConfig != nil && b.BuildWranglerConfig.Main != "" {
-		outBase, err := b.findModuleDir()
-		if err != nil {
-			return err
-		}
-		modulePath = filepath.Join(*outBase, b.BuildWranglerConfig.Main)
+	if b.Analyze {
+		_ = os.Remove(filepath.Join(absDir, b.GetMetafilePath()))
 	}
 
-	if shouldBundle {
+	if b.shouldBundle() {
 		start := time.Now()
 		utils.LogWithColor(utils.Cyan, "Bundling application...")
 
-		var warnedPackages = make(map[string][]string)
-
-		var cfPaths = make(map[string]struct{})
-
-		cloudflarePlugin := api.Plugin{
-			Name: "cloudflare-internal",
-			Setup: func(build api.PluginBuild) {
-				build.OnResolve(api.OnResolveOptions{Filter: "^cloudflare:"},
-					func(args api.OnResolveArgs) (api.OnResolveResult, error) {
-						cfPaths[args.Path] = struct{}{}
-						return api.OnResolveResult{External: true}, nil
-					})
-			},
-		}
-
-		nodejsHybridPlugin := plugins.NodeJsHybridPlugin{
-			BasePath:       absDir,
-			PackageManager: b.PackageManager,
-		}
-
-		externalFilesPlugin := plugins.ExternalFilePlugin{
-			Extensions: []string{
-				".wasm",
-				".bin",
-				".html",
-				".txt",
-			},
-		}
-
-		wranglerCDate := b.WranglerConfig.CompatibilityDate
-		if b.BuildWranglerConfig != nil && b.BuildWranglerConfig.CompatibilityDate != "" {
-			wranglerCDate = b.BuildWranglerConfig.CompatibilityDate
-		}
-
-		compatibilityDate, err := time.Parse(time.DateOnly, wranglerCDate)
+		options, err := b.buildOptions(absDir, modulePath)
 		if err != nil {
-			slog.Error("Invalid compatibility_date: must be in format YYYY-MM-DD")
-			return fmt.Errorf("invalid compatibility_date format: %w", err)
-		}
-
-		compatibilityFlags := b.WranglerConfig.CompatibilityFlags
-		if b.BuildWranglerConfig != nil && b.BuildWranglerConfig.CompatibilityFlags != nil {
-			compatibilityFlags = b.BuildWranglerConfig.CompatibilityFlags
+			return err
 		}
 
-		external := []string{"__STATIC_CONTENT_MANIFEST"}
-
-		// Check if the entrypoint file exists
-		if _, err := os.Stat(filepath.Join(absDir, modulePath)); err != nil {
-			entrypointFile := b.WranglerConfig.Main
-			if b.BuildWranglerConfig != nil && b.BuildWranglerConfig.Main != "" {
-				entrypointFile = b.BuildWranglerConfig.Main
-			}
-			if errors.Is(err, os.ErrNotExist) {
-				msg := fmt.Sprintf("The entry-point file at `%s` was not found.", entrypointFile)
-				slog.Error("" + msg)
-				return errors.New(msg)
-			}
-
-			msg := fmt.Sprintf("Could not find the entry-point file at `%s`.", entrypointFile)
-			slog.Error(""+msg, slog.Any("error", err))
-			return fmt.Errorf(msg+": %w", err)
-		}
-
-		result := api.Build(api.BuildOptions{
-			Plugins: []api.Plugin{
-				nodejsHybridPlugin.New(
-					compatibilityDate.Format(time.DateOnly),
-					compatibilityFlags,
-				),
-				externalFilesPlugin.New(),
-				cloudflarePlugin,
-			},
-			EntryPoints:    []string{modulePath},
-			Outdir:         b.GetModuleDir(),
-			AbsWorkingDir:  absDir,
-			Bundle:         true,
-			Write:          true,
-			AllowOverwrite: true,
-			Splitting:      true,
-			// LogLevel:       api.LogLevelInfo,
-			Format:      api.FormatESModule,
-			Platform:    api.PlatformNeutral,
-			TreeShaking: api.TreeShakingTrue,
-			Loader:      map[string]api.Loader{".js": api.LoaderJSX, ".mjs": api.LoaderJSX, ".cjs": api.LoaderJSX},
-
-			// Target modern runtime (Cloudflare Workers)
-			Target:            api.ESNext,
-			External:          external,
-			MinifyWhitespace:  true,
-			MinifyIdentifiers: true,
-			MinifySyntax:      true,
-			KeepNames:         true,
-			Metafile:          false,
-			Sourcemap:         api.SourceMapLinked,
-			Conditions:        []string{"workerd", "worker", "browser"},
-			Define: map[string]string{
-				"process.env.NODE_ENV":            toJSString(b.Environment),
-				"global.process.env.NODE_ENV":     toJSString(b.Environment),
-				"globalThis.process.env.NODE_ENV": toJSString(b.Environment),
-			},
-		})
+		result := api.Build(*options)
 
 		if len(result.Errors) > 0 {
 			for _, err := range result.Errors {
@@ -175,67 +98,342 @@ func (b *Bundle) Pack() error {
 			return fmt.Errorf("bundle failed with %d error(s)", len(result.Errors))
 		}
 
-		// Later, after build:
-		for path, importers := range warnedPackages {
-			slog.Warn(fmt.Sprintf("Node builtin %q used (from %v)\n", path, importers))
+		if b.Analyze {
+			err = os.WriteFile(filepath.Join(absDir, b.GetMetafilePath()), []byte(result.Metafile), 0644)
+			if err != nil {
+				slog.Error(fmt.Sprintf("%v", err))
+				return fmt.Errorf("could not write metafile: %w", err)
+			}
 		}
 
 		elapsed := time.Since(start)
 		utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Bundling completed in %s", elapsed))
 	} else {
+		if b.Analyze {
+			utils.LogWithColor(utils.Warning, "The worker is copied as-is without bundling, there is no metafile to analyze")
+		}
+
 		err = os.MkdirAll(filepath.Join(absDir, b.GetModuleDir()), 0755)
 		if err != nil {
 			slog.Error(fmt.Sprintf("%v", err))
 			return fmt.Errorf("could not create module directory: %w", err)
 		}
 
-		err = copyDir(filepath.Dir(filepath.Join(absDir, modulePath)), filepath.Join(absDir, b.GetModuleDir()), []string{})
+		err = copyDir(filepath.Dir(filepath.Join(absDir, modulePath)), filepath.Join(absDir, b.GetModuleDir()), []string{}, nil)
 		if err != nil {
 			slog.Error("Could not copy module files", slog.Any("error", err))
 			return fmt.Errorf("could not copy module files: %w", err)
 		}
 	}
 
-	now := time.Now()
+	err = b.copyAssets(absDir, modulePath)
+	if err != nil {
+		return err
+	}
+
+	err = b.writeDeployConfig(absDir)
+	if err != nil {
+		return err
+	}
+
+	if b.SizeBudget != nil {
+		return b.checkSizeBudget()
+	}
+
+	return nil
+}
+
+// shouldBundle reports whether the entrypoint has to go through esbuild, or
+// whether the framework output can be copied as-is.
+func (b *Bundle) shouldBundle() bool {
+	bundle, _ := b.ExplainBundling()
+	return bundle
+}
+
+// ExplainBundling reports whether the entrypoint goes through esbuild, along
+// with the reason.
+func (b *Bundle) ExplainBundling() (bool, string) {
+	if b.BuildWranglerConfig != nil {
+		if b.BuildWranglerConfig.NoBundle {
+			return false, "the wrangler configuration generated by the build sets `no_bundle`"
+		}
+		return true, "the wrangler configuration generated by the build does not set `no_bundle`"
+	}
+
+	if utils.IsOpenNext(b.WranglerConfig) {
+		return true, "the project is built with OpenNext"
+	}
+
+	if b.ShouldBundle {
+		return true, "requested with --bundle or by the framework"
+	}
+
+	return false, "the entrypoint is copied as-is, pass --bundle to bundle it"
+}
+
+// resolveModulePath returns the entrypoint relative to the root directory,
+// preferring the `main` of a wrangler config generated by the framework build.
+func (b *Bundle) resolveModulePath() (string, error) {
+	modulePath, _, err := b.ExplainModulePath()
+	return modulePath, err
+}
+
+// ExplainModulePath returns the entrypoint relative to the root directory
+// along with where it comes from. The reason is empty when the entrypoint is
+// the ModulePath of the bundle.
+func (b *Bundle) ExplainModulePath() (string, string, error) {
+	if b.BuildWranglerConfig != nil && b.BuildWranglerConfig.Main != "" {
+		outBase, err := b.findModuleDir()
+		if err != nil {
+			return "", "", err
+		}
+		return filepath.Join(*outBase, b.BuildWranglerConfig.Main), fmt.Sprintf("`main` of the wrangler configuration generated in `%s`", *outBase), nil
+	}
+
+	return b.ModulePath, "", nil
+}
 
+// buildOptions assembles the esbuild options used both for one-shot builds and
+// for long-lived watch contexts.
+func (b *Bundle) buildOptions(absDir, modulePath string) (*api.BuildOptions, error) {
+	var cfPaths = make(map[string]struct{})
+
+	cloudflarePlugin := api.Plugin{
+		Name: "cloudflare-internal",
+		Setup: func(build api.PluginBuild) {
+			build.OnResolve(api.OnResolveOptions{Filter: "^cloudflare:"},
+				func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+					cfPaths[args.Path] = struct{}{}
+					return api.OnResolveResult{External: true}, nil
+				})
+		},
+	}
+
+	nodejsHybridPlugin := plugins.NodeJsHybridPlugin{
+		BasePath: absDir,
+		Offline:  b.Offline,
+	}
+
+	denoConfig, err := utils.ReadDenoConfig(absDir)
+	if err != nil {
+		slog.Error(fmt.Sprintf("%v", err))
+		return nil, err
+	}
+
+	externalFilesPlugin := plugins.ExternalFilePlugin{
+		Extensions: []string{
+			".wasm",
+			".bin",
+			".html",
+			".txt",
+		},
+	}
+
+	wranglerCDate := b.WranglerConfig.CompatibilityDate
+	if b.BuildWranglerConfig != nil && b.BuildWranglerConfig.CompatibilityDate != "" {
+		wranglerCDate = b.BuildWranglerConfig.CompatibilityDate
+	}
+
+	compatibilityDate, err := time.Parse(time.DateOnly, wranglerCDate)
+	if err != nil {
+		slog.Error("Invalid compatibility_date: must be in format YYYY-MM-DD")
+		return nil, fmt.Errorf("invalid compatibility_date format: %w", err)
+	}
+
+	compatibilityFlags := b.WranglerConfig.CompatibilityFlags
+	if b.BuildWranglerConfig != nil && b.BuildWranglerConfig.CompatibilityFlags != nil {
+		compatibilityFlags = b.BuildWranglerConfig.CompatibilityFlags
+	}
+
+	external := []string{"__STATIC_CONTENT_MANIFEST"}
+
+	// Check if the entrypoint file exists
+	if _, err := os.Stat(filepath.Join(absDir, modulePath)); err != nil {
+		entrypointFile := b.WranglerConfig.Main
+		if b.BuildWranglerConfig != nil && b.BuildWranglerConfig.Main != "" {
+			entrypointFile = b.BuildWranglerConfig.Main
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			msg := fmt.Sprintf("The entry-point file at `%s` was not found.", entrypointFile)
+			slog.Error("" + msg)
+			return nil, errors.New(msg)
+		}
+
+		msg := fmt.Sprintf("Could not find the entry-point file at `%s`.", entrypointFile)
+		slog.Error(""+msg, slog.Any("error", err))
+		return nil, fmt.Errorf(msg+": %w", err)
+	}
+
+	buildPlugins := []api.Plugin{
+		nodejsHybridPlugin.New(
+			compatibilityDate.Format(time.DateOnly),
+			compatibilityFlags,
+		),
+		externalFilesPlugin.New(),
+		cloudflarePlugin,
+	}
+
+	if denoConfig != nil {
+		denoImportsPlugin := plugins.DenoImportsPlugin{Config: denoConfig}
+		buildPlugins = slices.Insert(buildPlugins, 0, denoImportsPlugin.New())
+	}
+
+	return &api.BuildOptions{
+		Plugins:        buildPlugins,
+		EntryPoints:    []string{modulePath},
+		Outdir:         b.GetModuleDir(),
+		AbsWorkingDir:  absDir,
+		NodePaths:      b.nodePaths(),
+		Bundle:         true,
+		Write:          true,
+		AllowOverwrite: true,
+		Splitting:      true,
+		// LogLevel:       api.LogLevelInfo,
+		Format:      api.FormatESModule,
+		Platform:    api.PlatformNeutral,
+		TreeShaking: api.TreeShakingTrue,
+		Loader:      map[string]api.Loader{".js": api.LoaderJSX, ".mjs": api.LoaderJSX, ".cjs": api.LoaderJSX},
+
+		// Target modern runtime (Cloudflare Workers)
+		Target:            api.ESNext,
+		External:          external,
+		MinifyWhitespace:  true,
+		MinifyIdentifiers: true,
+		MinifySyntax:      true,
+		KeepNames:         true,
+		Metafile:          b.Analyze,
+		Sourcemap:         api.SourceMapLinked,
+		Conditions:        []string{"workerd", "worker", "browser"},
+		Define: map[string]string{
+			"process.env.NODE_ENV":            toJSString(b.nodeEnv()),
+			"global.process.env.NODE_ENV":     toJSString(b.nodeEnv()),
+			"globalThis.process.env.NODE_ENV": toJSString(b.nodeEnv()),
+		},
+	}, nil
+}
+
+// nodeEnv returns the `process.env.NODE_ENV` of the bundle, independent of
+// the wrangler environment.
+func (b *Bundle) nodeEnv() string {
+	if b.NodeEnv == "" {
+		return "production"
+	}
+
+	return b.NodeEnv
+}
+
+// nodePaths lets esbuild resolve the dependencies hoisted to the root of the
+// workspace, whatever the layout of the project.
+func (b *Bundle) nodePaths() []string {
+	if b.Workspace == nil {
+		return nil
+	}
+
+	return []string{filepath.Join(b.Workspace.Root, "node_modules")}
+}
+
+// assetSource returns the absolute assets directory configured for the
+// project along with the paths that must not be copied from it, including the
+// ones listed in its `.assetsignore`. An empty directory means the project
+// has no assets.
+func (b *Bundle) assetSource(absDir, modulePath string) (string, []string) {
+	dir, ignore, _ := b.explainAssetSource(absDir, modulePath)
+	if dir == "" {
+		return "", nil
+	}
+
+	return dir, append(ignore, assetsIgnore(dir)...)
+}
+
+// LocateAssets returns the assets directory Pack copies, relative to the root
+// directory, along with where it comes from. The directory is empty when the
+// project has no assets.
+func (b *Bundle) LocateAssets() (string, string, error) {
+	absDir, err := filepath.Abs(b.RootDir)
+	if err != nil {
+		return "", "", err
+	}
+
+	modulePath, err := b.resolveModulePath()
+	if err != nil {
+		return "", "", err
+	}
+
+	dir, _, reason := b.explainAssetSource(absDir, modulePath)
+	if dir == "" {
+		return "", reason, nil
+	}
+
+	rel, err := filepath.Rel(absDir, dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	return rel, reason, nil
+}
+
+func (b *Bundle) explainAssetSource(absDir, modulePath string) (string, []string, string) {
 	if b.BuildWranglerConfig != nil && b.BuildWranglerConfig.Assets != nil && b.BuildWranglerConfig.Assets.Directory != "" {
 		dir := filepath.Join(absDir, filepath.Dir(modulePath), b.BuildWranglerConfig.Assets.Directory)
-		if _, err := os.Stat(dir); err == nil {
-
-			utils.LogWithColor(utils.Default, "Copying assets...")
+		return dir, moduleIgnore(dir, filepath.Join(absDir, modulePath)), "`assets.directory` of the wrangler configuration generated by the build"
+	}
 
-			modulePathDir := filepath.Join(absDir, filepath.Dir(modulePath))
-			err = copyDir(dir, b.GetAssetDir(), []string{modulePathDir})
-			if err != nil {
-				slog.Error(fmt.Sprintf("%v", err))
-				return fmt.Errorf("could not copy assets: %w", err)
-			}
+	if utils.HasAssets(b.WranglerConfig) && b.AssetPath != "" {
+		dir := filepath.Join(absDir, strings.TrimPrefix(b.AssetPath, "/"))
+		return dir, moduleIgnore(dir, filepath.Join(absDir, b.ModulePath)), "`assets.directory` of the wrangler configuration"
+	}
 
-			elapsed := time.Since(now)
-			utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Assets copied in %s", elapsed))
-		} else if !errors.Is(err, os.ErrNotExist) {
-			slog.Error(fmt.Sprintf("%v", err))
-			return fmt.Errorf("could not stat assets directory: %w", err)
+	if b.Framework != nil {
+		if assets, err := b.Framework.LocateAssets(b); err == nil && assets != "" {
+			dir := filepath.Join(absDir, assets)
+			return dir, moduleIgnore(dir, filepath.Join(absDir, modulePath)), fmt.Sprintf("assets of the `%s` framework", b.Framework.Name())
 		}
-	} else if utils.HasAssets(b.WranglerConfig) && b.AssetPath != "" {
-		if _, err := os.Stat(filepath.Join(absDir, b.AssetPath)); err == nil {
+	}
+
+	return "", nil, "no assets directory is configured"
+}
 
-			utils.LogWithColor(utils.Default, "Copying assets...")
+// moduleIgnore returns the path that keeps the worker out of the assets: the
+// directory of the entrypoint, or only the entrypoint when it sits at the root
+// of the assets directory.
+func moduleIgnore(assetDir, moduleFile string) []string {
+	if filepath.Dir(moduleFile) == assetDir {
+		return []string{moduleFile}
+	}
 
-			modulePathDir := filepath.Join(absDir, filepath.Dir(b.ModulePath))
-			err = copyDir(filepath.Join(absDir, strings.TrimPrefix(b.AssetPath, "/")), b.GetAssetDir(), []string{modulePathDir})
+	return []string{filepath.Dir(moduleFile)}
+}
 
-			if err != nil {
-				slog.Error(fmt.Sprintf("%v", err))
-				return fmt.Errorf("could not copy assets: %w", err)
-			}
-			elapsed := time.Since(now)
-			utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Assets copied in %s", elapsed))
+func (b *Bundle) copyAssets(absDir, modulePath string) error {
+	dir, ignore := b.assetSource(absDir, modulePath)
+	if dir == "" {
+		return nil
+	}
+
+	now := time.Now()
 
-		} else if !errors.Is(err, os.ErrNotExist) {
+	if _, err := os.Stat(dir); err == nil {
+		utils.LogWithColor(utils.Default, "Copying assets...")
+
+		manifest := AssetManifest{}
+		err = copyDir(dir, b.GetAssetDir(), ignore, manifest)
+		if err != nil {
 			slog.Error(fmt.Sprintf("%v", err))
-			return fmt.Errorf("could not stat assets directory: %w", err)
+			return fmt.Errorf("could not copy assets: %w", err)
+		}
+
+		elapsed := time.Since(now)
+		utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Assets copied in %s", elapsed))
+
+		err = b.writeAssetManifest(absDir, manifest)
+		if err != nil {
+			return err
 		}
+
+		return b.checkAssetRules(absDir, dir, manifest)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		slog.Error(fmt.Sprintf("%v", err))
+		return fmt.Errorf("could not stat assets directory: %w", err)
 	}
 
 	return nil
@@ -248,23 +446,45 @@ func (b *Bundle) RunBuildCommand() error {
 		return fmt.Errorf("could not resolve absolute path: %w", err)
 	}
 
-	checks, err := utils.HasCloudflareVitePlugin(absDir)
-	if err == nil && checks != nil {
-		if !checks.HasDependency {
-			msg := "We couldn't find the @cloudflare/vite-plugin-cloudflare dependency in your package.json. Please install it."
-			slog.Warn(msg)
+	var args []string
+
+	if b.InjectVitePlugin {
+		if err := b.addVitePluginDependency(absDir); err != nil {
+			slog.Error(fmt.Sprintf("%v", err))
+			return err
+		}
+
+		config, err := utils.IncludeCloudflareVitePlugin(absDir, b.viteEnvironment())
+		if err != nil {
+			slog.Error(fmt.Sprintf("%v", err))
+			return err
+		}
+		if config != nil {
+			defer func() {
+				_ = os.Remove(filepath.Join(absDir, *config))
+			}()
+			args = append(args, "--config", *config)
 		}
-		if !checks.IsPlugin {
-			msg := "we couldn't find the `@cloudflare/vite-plugin-cloudflare` plugin usage in your vite.config.js."
-			slog.Warn(msg)
+	} else {
+		checks, err := utils.HasCloudflareVitePlugin(absDir)
+		if err == nil && checks != nil {
+			if !checks.HasDependency {
+				msg := "We couldn't find the @cloudflare/vite-plugin dependency in your package.json. Please install it, or build with --vite-plugin."
+				slog.Warn(msg)
+			}
+			if !checks.IsPlugin {
+				msg := "we couldn't find the `@cloudflare/vite-plugin` plugin usage in your vite.config.js. Add it, or build with --vite-plugin."
+				slog.Warn(msg)
+			}
 		}
 	}
 
-	cmdName := b.PackageManager + " run " + b.BuildScript
+	name, argv, dir := b.scriptCommand(b.BuildScript, args...)
+	cmdName := strings.Join(append([]string{name}, argv...), " ")
 
 	start := time.Now()
 	utils.LogWithColor(utils.Default, fmt.Sprintf("Running `%s`...", cmdName))
-	err = b.RunCommand(b.PackageManager, "run", b.BuildScript)
+	err = b.runCommandIn(dir, name, argv...)
 
 	if err != nil {
 		slog.Error(fmt.Sprintf("%v", err))
@@ -273,6 +493,28 @@ func (b *Bundle) RunBuildCommand() error {
 	elapsed := time.Since(start)
 	utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Completed `%s` in %s", cmdName, elapsed))
 
+	return nil
+}
+
+// viteEnvironment returns the Vite environment the worker of the framework
+// runs in, or an empty string to let `@cloudflare/vite-plugin` create one.
+func (b *Bundle) viteEnvironment() string {
+	if framework, ok := b.Framework.(ViteFramework); ok {
+		return framework.ViteEnvironment()
+	}
+
+	return ""
+}
+
+// LoadBuildWranglerConfig reads the wrangler configuration a framework build
+// generated next to its server output, if any, into BuildWranglerConfig.
+func (b *Bundle) LoadBuildWranglerConfig() error {
+	absDir, err := filepath.Abs(b.RootDir)
+	if err != nil {
+		slog.Error(fmt.Sprintf("%v", err))
+		return fmt.Errorf("could not resolve absolute path: %w", err)
+	}
+
 	outDir, err := b.findModuleDir()
 	if err != nil {
 		slog.Error(fmt.Sprintf("%v", err))
@@ -293,19 +535,68 @@ func (b *Bundle) RunBuildCommand() error {
 }
 
 func (b *Bundle) findModuleDir() (*string, error) {
+	dir, _, err := b.LocateModuleDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return &dir, nil
+}
+
+// LocateModuleDir returns the directory of the server output, relative to the
+// root directory, along with why it was picked: it is the first existing one
+// of the directory a `.wrangler/deploy/config.json` redirects to, the server
+// output of the framework, `dist/micromachine` and the directory of `main`.
+func (b *Bundle) LocateModuleDir() (string, string, error) {
 	absDir, err := filepath.Abs(b.RootDir)
 	if err != nil {
 		slog.Error(fmt.Sprintf("%v", err))
-		return nil, err
+		return "", "", err
 	}
 
+	candidates, err := b.moduleDirCandidates(absDir)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, c := range candidates {
+		pathWithAbsDir := filepath.Join(absDir, c.path)
+		if info, err := os.Stat(pathWithAbsDir); err == nil && info.IsDir() {
+			return c.path, c.reason, nil
+		}
+	}
+
+	return filepath.Dir(b.WranglerConfig.Main), "no candidate exists yet, falling back to the directory of `main`", nil
+}
+
+// moduleDirCandidate is a directory LocateModuleDir picks when it exists.
+type moduleDirCandidate struct {
+	path   string
+	reason string
+}
+
+// moduleDirCandidates returns the directories LocateModuleDir picks from, in
+// order.
+func (b *Bundle) moduleDirCandidates(absDir string) ([]moduleDirCandidate, error) {
 	mainDir := filepath.Dir(b.WranglerConfig.Main)
 
-	paths := []string{
-		"dist/server",
-		".output/server",
-		"dist/micromachine",
-		mainDir,
+	// `dist/server` and `.output/server` are fallbacks for generic projects
+	// built with --script, after the server output of the framework.
+	candidates := []moduleDirCandidate{
+		{"dist/server", "found `dist/server`"},
+		{".output/server", "found `.output/server`"},
+		{"dist/micromachine", "default output directory"},
+		{mainDir, "directory of `main`"},
+	}
+
+	if b.Framework != nil {
+		serverOutput, err := b.Framework.LocateServerOutput(b)
+		if err != nil {
+			return nil, err
+		}
+		if serverOutput != "" {
+			candidates = slices.Insert(candidates, 0, moduleDirCandidate{serverOutput, fmt.Sprintf("server output of the `%s` framework", b.Framework.Name())})
+		}
 	}
 
 	// Check for generated deployment config (from framework builds)
@@ -324,41 +615,107 @@ func (b *Bundle) findModuleDir() (*string, error) {
 				slog.Error(fmt.Sprintf("There is a deploy configuration at `.wrangler/deploy/config.json`. But the redirected configuration path it points to, `%s`, does not exist.", deployConfig.ConfigPath))
 				return nil, err
 			}
-			paths = slices.Insert(paths, 0, generatedDir)
+			if rel, err := filepath.Rel(absDir, generatedDir); err == nil {
+				candidates = slices.Insert(candidates, 0, moduleDirCandidate{rel, "redirected to by `.wrangler/deploy/config.json`"})
+			}
 		}
 	}
 
-	for _, p := range paths {
-		pathWithAbsDir := filepath.Join(absDir, p)
-		if info, err := os.Stat(pathWithAbsDir); err == nil && info.IsDir() {
-			return &p, nil
+	return candidates, nil
+}
+
+// OutputDirs returns the directories, relative to the root directory, that a
+// build writes its output to: the candidates of LocateModuleDir, the assets
+// directory and the output of the framework.
+func (b *Bundle) OutputDirs() []string {
+	var dirs []string
+
+	absDir, err := filepath.Abs(b.RootDir)
+	if err != nil {
+		return nil
+	}
+
+	if candidates, err := b.moduleDirCandidates(absDir); err == nil {
+		for _, c := range candidates {
+			dirs = append(dirs, c.path)
 		}
 	}
 
-	return &mainDir, nil
+	if b.AssetPath != "" {
+		dirs = append(dirs, b.AssetPath)
+	}
+
+	if b.Framework != nil {
+		if assets, err := b.Framework.LocateAssets(b); err == nil && assets != "" {
+			dirs = append(dirs, assets)
+		}
+		if framework, ok := b.Framework.(OutputFramework); ok {
+			output := framework.Output(b)
+			dirs = append(dirs, filepath.Dir(output.ModulePath), output.AssetPath)
+		}
+	}
+
+	for i, dir := range dirs {
+		dirs[i] = filepath.Clean(dir)
+	}
+	dirs = slices.DeleteFunc(dirs, func(dir string) bool {
+		return dir == "." || dir == ""
+	})
+	slices.Sort(dirs)
+	return slices.Compact(dirs)
 }
 
+// RunExecutableCommand runs the executable of a package, args[0], with the
+// rest of args, e.g. `npx wrangler types`.
 func (b *Bundle) RunExecutableCommand(args ...string) error {
-	switch b.PackageManager {
-	case "npm":
-		return b.RunCommand("npx", args...)
-	case "yarn":
-		args = append([]string{"dlx"}, args...)
-		return b.RunCommand("yarn", args...)
-	case "pnpm":
-		args = append([]string{"dlx"}, args...)
-		return b.RunCommand("pnpm", args...)
-	case "bun":
-		return b.RunCommand("bunx", args...)
+	if len(args) == 0 {
+		return errors.New("no executable to run")
 	}
 
-	return fmt.Errorf("invalid package manager %s", b.PackageManager)
+	name, argv := b.PackageManager.Exec(args[0], args[1:]...)
+	return b.RunCommand(name, argv...)
+}
+
+// addVitePluginDependency installs `@cloudflare/vite-plugin` in vite apps
+// whose package.json does not list it.
+func (b *Bundle) addVitePluginDependency(absDir string) error {
+	if _, ok := utils.IsViteApp(absDir); !ok {
+		return nil
+	}
+
+	packageJSON, err := utils.ReadPackageJSON(absDir)
+	if err != nil || packageJSON.HasDependency("@cloudflare/vite-plugin") {
+		return nil
+	}
+
+	return b.AddDevDependencies("@cloudflare/vite-plugin")
+}
+
+// scriptCommand returns the command running a package.json script, or a
+// Deno task, with args, along with the directory to run it in. Scripts of
+// workspace packages run from the workspace root, selecting the package with
+// the filter of the package manager.
+func (b *Bundle) scriptCommand(script string, args ...string) (string, []string, string) {
+	if b.Workspace == nil || b.Workspace.Package == "" || b.PackageManager.Name == "deno" {
+		name, argv := b.PackageManager.Run(script, args...)
+		return name, argv, b.RootDir
+	}
+
+	name, argv := b.PackageManager.RunWorkspace(b.Workspace.Package, script, args...)
+	return name, argv, b.Workspace.Root
 }
 
 func (b *Bundle) RunCommand(name string, args ...string) error {
+	return b.runCommandIn(b.RootDir, name, args...)
+}
+
+func (b *Bundle) runCommandIn(dir, name string, args ...string) error {
 	// Capture stdout and stderr separately
 	cmd := exec.Command(name, args...)
-	cmd.Dir = b.RootDir
+	cmd.Dir = dir
+	if len(b.CommandEnv) > 0 {
+		cmd.Env = append(os.Environ(), b.CommandEnv...)
+	}
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	err := cmd.Run()
@@ -378,11 +735,35 @@ func (b *Bundle) GetModuleDir() string {
 	return filepath.Join(b.GetOutputDir(), "/worker")
 }
 
+// GetMetafilePath returns the path, relative to the root directory, of the
+// esbuild metafile written when Analyze is set.
+func (b *Bundle) GetMetafilePath() string {
+	return filepath.Join(b.GetOutputDir(), "meta.json")
+}
+
+// GetModuleEntry returns the path of the packed entrypoint, relative to
+// GetModuleDir.
+func (b *Bundle) GetModuleEntry() (string, error) {
+	modulePath, err := b.resolveModulePath()
+	if err != nil {
+		return "", err
+	}
+
+	name := filepath.Base(modulePath)
+	if b.shouldBundle() {
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + ".js"
+	}
+
+	return name, nil
+}
+
 func (b *Bundle) GetAssetDir() string {
 	return filepath.Join(b.RootDir, ".micromachine/assets")
 }
 
-func copyDir(src, dst string, ignorePath []string) error {
+// copyDir copies src into dst, skipping the ignored paths. When manifest is
+// not nil, every copied file is recorded in it.
+func copyDir(src, dst string, ignorePath []string, manifest AssetManifest) error {
 
 	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -391,15 +772,8 @@ func copyDir(src, dst string, ignorePath []string) error {
 		rel, _ := filepath.Rel(src, path)
 		target := filepath.Join(dst, rel)
 
-		for _, p := range ignorePath {
-			pathRel, err := filepath.Rel(src, p)
-			if err != nil {
-				continue
-			}
-
-			if strings.HasPrefix(rel, pathRel) {
-				return nil
-			}
+		if isIgnored(src, rel, ignorePath) {
+			return nil
 		}
 
 		if d.IsDir() {
@@ -412,11 +786,58 @@ func copyDir(src, dst string, ignorePath []string) error {
 			return err
 		}
 
+		if manifest != nil {
+			manifest.add(rel, data)
+		}
+
 		info, _ := d.Info()
 		return os.WriteFile(target, data, info.Mode())
 	})
 }
 
+// assetsIgnore returns the paths of dir matched by the patterns of its
+// `.assetsignore` file, which are not uploaded as assets, along with the file
+// itself.
+func assetsIgnore(dir string) []string {
+	data, err := os.ReadFile(filepath.Join(dir, ".assetsignore"))
+	if err != nil {
+		return nil
+	}
+
+	paths := []string{filepath.Join(dir, ".assetsignore")}
+	for _, line := range strings.Split(string(data), "\n") {
+		pattern := strings.TrimSpace(line)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(dir, strings.Trim(pattern, "/")))
+		if err != nil {
+			continue
+		}
+		paths = append(paths, matches...)
+	}
+
+	return paths
+}
+
+// isIgnored reports whether rel, relative to src, lives under one of the
+// ignored paths.
+func isIgnored(src, rel string, ignorePath []string) bool {
+	for _, p := range ignorePath {
+		pathRel, err := filepath.Rel(src, p)
+		if err != nil {
+			continue
+		}
+
+		if strings.HasPrefix(rel, pathRel) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func toJSString(val string) string {
 	if val == "" {
 		return `""` // or "undefined" depending on your needs
@@ -0,0 +1,130 @@
+package bundler
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// defaultWatchDir is the directory wrangler watches for a custom build that
+// sets no `watch_dir`.
+const defaultWatchDir = "src"
+
+// CustomBuild returns the `[build]` section of the wrangler configuration,
+// nil when it sets no command.
+func (b *Bundle) CustomBuild() *utils.BuildConfig {
+	if b.WranglerConfig == nil || b.WranglerConfig.Build == nil || b.WranglerConfig.Build.Command == "" {
+		return nil
+	}
+
+	return b.WranglerConfig.Build
+}
+
+// RunsCustomBuild reports whether the custom build replaces the build script:
+// when the wrangler configuration sets a `build.command` and no script is
+// given with `--script`.
+func (b *Bundle) RunsCustomBuild() bool {
+	return b.BuildScript == "" && b.CustomBuild() != nil
+}
+
+// RunCustomBuild runs the `build.command` of the wrangler configuration with
+// the shell, from `build.cwd` relative to the root directory, as wrangler
+// does.
+func (b *Bundle) RunCustomBuild() error {
+	build := b.CustomBuild()
+	if build == nil {
+		return nil
+	}
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	start := time.Now()
+	utils.LogWithColor(utils.Default, fmt.Sprintf("Running `%s`...", build.Command))
+	if err := b.runCommandIn(filepath.Join(b.RootDir, build.Cwd), shell, flag, build.Command); err != nil {
+		slog.Error(fmt.Sprintf("%v", err))
+		return fmt.Errorf("custom build command failed: %w", err)
+	}
+
+	utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Completed `%s` in %s", build.Command, time.Since(start)))
+	return nil
+}
+
+// customBuildWatcher re-runs the custom build when a file of its watch
+// directory changes.
+type customBuildWatcher struct {
+	dir  string
+	seen map[string]fileStamp
+}
+
+// newCustomBuildWatcher returns a watcher of the `build.watch_dir` of the
+// wrangler configuration, `src` by default, or nil when there is no custom
+// build.
+func (b *Bundle) newCustomBuildWatcher(absDir string) *customBuildWatcher {
+	build := b.CustomBuild()
+	if build == nil {
+		return nil
+	}
+
+	dir := build.WatchDir
+	if dir == "" {
+		dir = defaultWatchDir
+	}
+
+	watcher := &customBuildWatcher{dir: filepath.Join(absDir, dir)}
+	watcher.seen, _ = snapshotDir(watcher.dir)
+	return watcher
+}
+
+// changed reports whether a file of the watch directory was added, removed
+// or modified since the previous call.
+func (w *customBuildWatcher) changed() bool {
+	current, err := snapshotDir(w.dir)
+	if err != nil {
+		return false
+	}
+
+	if maps.Equal(current, w.seen) {
+		return false
+	}
+
+	w.seen = current
+	return true
+}
+
+// relDir returns the watch directory relative to absDir, e.g. `src`.
+func (w *customBuildWatcher) relDir(absDir string) string {
+	if rel, err := filepath.Rel(absDir, w.dir); err == nil {
+		return rel
+	}
+
+	return w.dir
+}
+
+func snapshotDir(dir string) (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		stamps[path] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+
+	return stamps, err
+}
@@ -0,0 +1,137 @@
+package bundler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// deployModuleRules lets wrangler upload the chunks and non-JS modules that
+// sit next to the packed entrypoint without bundling them again.
+var deployModuleRules = []utils.ModuleRule{
+	{Type: "ESModule", Globs: []string{"**/*.js", "**/*.mjs"}},
+	{Type: "CompiledWasm", Globs: []string{"**/*.wasm"}},
+	{Type: "Data", Globs: []string{"**/*.bin"}},
+	{Type: "Text", Globs: []string{"**/*.txt", "**/*.html"}},
+}
+
+// GetDeployConfigPath returns the path, relative to the root directory, of
+// the wrangler configuration describing the packed output.
+func (b *Bundle) GetDeployConfigPath() string {
+	return filepath.Join(b.GetOutputDir(), "wrangler.json")
+}
+
+// DeployConfig returns the normalized wrangler configuration of the packed
+// output: `main` points at the packed entrypoint, `assets.directory` at the
+// copied assets and bundling is disabled, so the output directory can be
+// deployed on its own.
+func (b *Bundle) DeployConfig() (*utils.NormalizedWranglerConfig, error) {
+	var config utils.NormalizedWranglerConfig
+	if b.BuildWranglerConfig != nil {
+		config = *b.BuildWranglerConfig
+	} else if b.WranglerConfig != nil {
+		config = *b.WranglerConfig.Normalize()
+	}
+
+	if b.WranglerConfig != nil {
+		if config.Name == "" {
+			config.Name = b.WranglerConfig.Name
+		}
+		if config.CompatibilityDate == "" {
+			config.CompatibilityDate = b.WranglerConfig.CompatibilityDate
+		}
+	}
+
+	// The configuration is already resolved for the selected environment.
+	config.ConfigPath = ""
+	config.UserConfigPath = ""
+	config.TopLevelName = ""
+	config.DefinedEnvironments = nil
+	config.Env = nil
+
+	entry, err := b.GetModuleEntry()
+	if err != nil {
+		return nil, err
+	}
+
+	moduleDir, _ := filepath.Rel(b.GetOutputDir(), b.GetModuleDir())
+	config.Main = filepath.ToSlash(filepath.Join(moduleDir, entry))
+	config.NoBundle = true
+	config.FindAdditionalModules = true
+	config.Rules = append(append([]utils.ModuleRule{}, config.Rules...), deployModuleRules...)
+
+	// Assets located by the framework adapter are not in the wrangler
+	// configuration, but still have to be uploaded.
+	if config.Assets == nil && b.Framework != nil {
+		if info, err := os.Stat(b.GetAssetDir()); err == nil && info.IsDir() {
+			config.Assets = &utils.AssetsConfig{}
+		}
+	}
+
+	if config.Assets != nil {
+		assets := *config.Assets
+		assets.Directory = ""
+
+		if info, err := os.Stat(b.GetAssetDir()); err == nil && info.IsDir() {
+			assetDir, _ := filepath.Rel(filepath.Join(b.RootDir, b.GetOutputDir()), b.GetAssetDir())
+			assets.Directory = filepath.ToSlash(assetDir)
+		}
+
+		config.Assets = &assets
+		if assets == (utils.AssetsConfig{}) {
+			config.Assets = nil
+		}
+	}
+
+	return &config, nil
+}
+
+func (b *Bundle) writeDeployConfig(absDir string) error {
+	config, err := b.DeployConfig()
+	if err != nil {
+		slog.Error(fmt.Sprintf("%v", err))
+		return fmt.Errorf("could not resolve deploy configuration: %w", err)
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode deploy configuration: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(absDir, b.GetDeployConfigPath()), append(data, '\n'), 0644)
+	if err != nil {
+		slog.Error(fmt.Sprintf("%v", err))
+		return fmt.Errorf("could not write deploy configuration: %w", err)
+	}
+
+	utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Wrote `%s`", filepath.ToSlash(filepath.Clean(b.GetDeployConfigPath()))))
+	return nil
+}
+
+// PackedModuleEntry returns the entrypoint Pack wrote, relative to
+// GetModuleDir, as recorded by `main` in the deploy configuration. Unlike
+// GetModuleEntry, it reflects what the framework adapter selected during the
+// build.
+func (b *Bundle) PackedModuleEntry() (string, error) {
+	data, err := os.ReadFile(filepath.Join(b.RootDir, b.GetDeployConfigPath()))
+	if err != nil {
+		return "", fmt.Errorf("could not read `%s`, run `micromachine build` first: %w", filepath.ToSlash(filepath.Clean(b.GetDeployConfigPath())), err)
+	}
+
+	var config struct {
+		Main string `json:"main"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", fmt.Errorf("could not parse `%s`: %w", filepath.ToSlash(filepath.Clean(b.GetDeployConfigPath())), err)
+	}
+	if config.Main == "" {
+		return "", fmt.Errorf("`%s` sets no `main`, run `micromachine build` again", filepath.ToSlash(filepath.Clean(b.GetDeployConfigPath())))
+	}
+
+	moduleDir, _ := filepath.Rel(b.GetOutputDir(), b.GetModuleDir())
+	return filepath.Rel(moduleDir, filepath.FromSlash(config.Main))
+}
@@ -0,0 +1,55 @@
+package bundler
+
+// FrameworkAdapter builds the project of a framework and tells Pack where the
+// build put the server output and the client assets.
+type FrameworkAdapter interface {
+	// Name is the name of the framework, e.g. `nuxt`.
+	Name() string
+	// Detect reports whether the project in rootDir uses the framework.
+	Detect(rootDir string) bool
+	// Build runs the framework build.
+	Build(b *Bundle) error
+	// LocateServerOutput returns the directory of the server output, relative
+	// to the root directory, or an empty string when it is not known.
+	LocateServerOutput(b *Bundle) (string, error)
+	// LocateAssets returns the directory of the client assets, relative to
+	// the root directory, or an empty string when it is not known. Assets
+	// configured in wrangler take precedence.
+	LocateAssets(b *Bundle) (string, error)
+	// PostProcess runs after Build and before Pack, e.g. to read the wrangler
+	// configuration generated by the build.
+	PostProcess(b *Bundle) error
+}
+
+// ViteFramework is implemented by the adapters of frameworks built by Vite,
+// whose server runs in a Vite environment of its own.
+type ViteFramework interface {
+	// ViteEnvironment is the name of the Vite environment of the server,
+	// e.g. `ssr`, given to `@cloudflare/vite-plugin`.
+	ViteEnvironment() string
+}
+
+// OutputFramework is implemented by the adapters whose build output is packed
+// whatever `main` and `assets.directory` are set to in wrangler.
+type OutputFramework interface {
+	// Output returns the output the build writes, whether or not it was
+	// built yet.
+	Output(b *Bundle) FrameworkOutput
+}
+
+// FrameworkOutput is the entrypoint and assets directory a framework build
+// writes, relative to the root directory.
+type FrameworkOutput struct {
+	ModulePath string
+	AssetPath  string
+	// ShouldBundle is set when the entrypoint imports modules that have to
+	// be bundled with it.
+	ShouldBundle bool
+}
+
+// UseFrameworkOutput points the bundle at the output of the framework build.
+func (b *Bundle) UseFrameworkOutput(output FrameworkOutput) {
+	b.ModulePath = output.ModulePath
+	b.AssetPath = output.AssetPath
+	b.ShouldBundle = b.ShouldBundle || output.ShouldBundle
+}
@@ -0,0 +1,78 @@
+package bundler
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// InstallDir returns the directory the dependencies of the project are
+// installed from: the root of its workspace, or the root directory.
+func (b *Bundle) InstallDir() string {
+	if b.Workspace != nil {
+		return b.Workspace.Root
+	}
+
+	return b.RootDir
+}
+
+// UniqueInstalls returns the first bundle of every install directory, since
+// the projects of a workspace share the install of its root.
+func UniqueInstalls(bundles []*Bundle) []*Bundle {
+	seen := map[string]bool{}
+
+	var unique []*Bundle
+	for _, bundle := range bundles {
+		if seen[bundle.InstallDir()] {
+			continue
+		}
+		seen[bundle.InstallDir()] = true
+		unique = append(unique, bundle)
+	}
+
+	return unique
+}
+
+// InstallDependencies runs the frozen install of the package manager, e.g.
+// `npm ci` or `pnpm install --frozen-lockfile`, which fails instead of
+// updating an outdated lockfile.
+func (b *Bundle) InstallDependencies() error {
+	name, args := b.PackageManager.Install(true)
+	cmdName := strings.Join(append([]string{name}, args...), " ")
+
+	start := time.Now()
+	utils.LogWithColor(utils.Default, fmt.Sprintf("Running `%s`...", cmdName))
+	if err := b.runCommandIn(b.InstallDir(), name, args...); err != nil {
+		return fmt.Errorf("`%s` failed: %w", cmdName, err)
+	}
+
+	utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Completed `%s` in %s", cmdName, time.Since(start)))
+	return nil
+}
+
+// AddDevDependencies installs packages the build needs but package.json does
+// not list. The install changes package.json and the lockfile, so it is
+// reported as lockfile drift, and refused when Frozen is set.
+func (b *Bundle) AddDevDependencies(packages ...string) error {
+	names := make([]string, len(packages))
+	for i, pkg := range packages {
+		names[i] = "`" + pkg + "`"
+	}
+	list := strings.Join(names, ", ")
+
+	if b.Frozen {
+		return fmt.Errorf("the build needs %s, which package.json does not list, and installing it would change the lockfile; add it to the dev dependencies, or build without --frozen", list)
+	}
+
+	slog.Warn(fmt.Sprintf("Lockfile drift: installing %s, which package.json does not list. Add it to the dev dependencies to keep builds reproducible.", list))
+
+	name, args := b.PackageManager.AddDev(packages...)
+	if err := b.RunCommand(name, args...); err != nil {
+		return fmt.Errorf("could not install %s: %w", list, err)
+	}
+
+	return nil
+}
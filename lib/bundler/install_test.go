@@ -0,0 +1,104 @@
+package bundler
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// fakePackageManager puts an executable named after the package manager first
+// on the PATH, which records its arguments in `args.txt` of its directory.
+func fakePackageManager(t *testing.T, name string) {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake package manager of the test needs sh")
+	}
+
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho \"$@\" > args.txt\n"
+	if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestInstallDependencies(t *testing.T) {
+	tests := []struct {
+		name      string
+		pm        utils.PackageManager
+		workspace bool
+		expected  string
+	}{
+		{"npm", utils.PackageManager{Name: "npm"}, false, "ci"},
+		{"pnpm", utils.PackageManager{Name: "pnpm"}, false, "install --frozen-lockfile"},
+		{"yarn classic", utils.PackageManager{Name: "yarn", Version: "1.22.22"}, false, "install --frozen-lockfile"},
+		{"yarn berry", utils.PackageManager{Name: "yarn", Version: "4.5.0"}, false, "install --immutable"},
+		{"bun", utils.PackageManager{Name: "bun"}, false, "install --frozen-lockfile"},
+		{"deno", utils.PackageManager{Name: "deno"}, false, "install --frozen"},
+		{"Member of a workspace", utils.PackageManager{Name: "pnpm"}, true, "install --frozen-lockfile"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakePackageManager(t, tt.pm.Name)
+
+			root := t.TempDir()
+			b := &Bundle{RootDir: root, PackageManager: tt.pm}
+			if tt.workspace {
+				b.RootDir = filepath.Join(root, "apps", "web")
+				if err := os.MkdirAll(b.RootDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				b.Workspace = &utils.Workspace{Root: root}
+			}
+
+			if err := b.InstallDependencies(); err != nil {
+				t.Fatalf("InstallDependencies() error = %v", err)
+			}
+
+			args, err := os.ReadFile(filepath.Join(root, "args.txt"))
+			if err != nil {
+				t.Fatalf("Expected the install to run in %s: %v", root, err)
+			}
+			if got := strings.TrimSpace(string(args)); got != tt.expected {
+				t.Errorf("Expected `%s %s`, got `%s %s`", tt.pm.Name, tt.expected, tt.pm.Name, got)
+			}
+		})
+	}
+}
+
+func TestAddDevDependenciesFrozen(t *testing.T) {
+	fakePackageManager(t, "npm")
+
+	b := &Bundle{RootDir: t.TempDir(), PackageManager: utils.PackageManager{Name: "npm"}, Frozen: true}
+
+	err := b.AddDevDependencies("@cloudflare/vite-plugin")
+	if err == nil || !strings.Contains(err.Error(), "--frozen") {
+		t.Errorf("Expected the install to be refused with --frozen, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(b.RootDir, "args.txt")); err == nil {
+		t.Error("Expected the package manager not to run")
+	}
+}
+
+func TestUniqueInstalls(t *testing.T) {
+	workspace := &utils.Workspace{Root: "/repo"}
+
+	web := &Bundle{RootDir: "/repo/apps/web", Workspace: workspace}
+	api := &Bundle{RootDir: "/repo/apps/api", Workspace: workspace}
+	standalone := &Bundle{RootDir: "/repo/tools/worker"}
+	duplicate := &Bundle{RootDir: "/repo/tools/worker"}
+
+	got := UniqueInstalls([]*Bundle{web, standalone, api, duplicate})
+	if want := []*Bundle{web, standalone}; !slices.Equal(got, want) {
+		t.Errorf("UniqueInstalls() = %v, want %v", got, want)
+	}
+}
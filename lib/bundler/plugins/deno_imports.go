@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// denoResolved marks the resolutions started by DenoImportsPlugin, so that
+// an import map entry such as `"hono": "npm:hono@4"` does not loop.
+type denoResolved struct{}
+
+// DenoImportsPlugin resolves the specifiers of the import map of a Deno
+// project and `npm:` specifiers, which esbuild does not understand. npm
+// packages are resolved from node_modules, so the project has to install them
+// there, e.g. with `"nodeModulesDir": "auto"`.
+type DenoImportsPlugin struct {
+	Config *utils.DenoConfig
+}
+
+func (p *DenoImportsPlugin) New() api.Plugin {
+	return api.Plugin{
+		Name: "deno-imports",
+		Setup: func(build api.PluginBuild) {
+			build.OnResolve(api.OnResolveOptions{Filter: ".*"}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+				if _, ok := args.PluginData.(denoResolved); ok || args.Kind == api.ResolveEntryPoint {
+					return api.OnResolveResult{}, nil
+				}
+
+				specifier, mapped := p.Config.ResolveImport(args.Path)
+				if !mapped {
+					specifier = args.Path
+				}
+
+				switch {
+				case strings.HasPrefix(specifier, "npm:"):
+					pkg, err := utils.NpmSpecifierPackage(specifier)
+					if err != nil {
+						return api.OnResolveResult{}, err
+					}
+					specifier = pkg
+				case strings.HasPrefix(specifier, "jsr:"):
+					return api.OnResolveResult{}, fmt.Errorf("`%s` is a JSR specifier, which micromachine cannot bundle yet; import the package from npm instead", specifier)
+				case strings.HasPrefix(specifier, "http:") || strings.HasPrefix(specifier, "https:"):
+					return api.OnResolveResult{}, fmt.Errorf("`%s` is a remote import, which Workers cannot load; vendor it or import it from npm", specifier)
+				case !mapped:
+					return api.OnResolveResult{}, nil
+				}
+
+				result := build.Resolve(specifier, api.ResolveOptions{
+					Importer:   args.Importer,
+					ResolveDir: args.ResolveDir,
+					Kind:       args.Kind,
+					PluginData: denoResolved{},
+				})
+				if len(result.Errors) > 0 {
+					return api.OnResolveResult{}, fmt.Errorf("could not resolve `%s` (mapped to `%s`): %s", args.Path, specifier, result.Errors[0].Text)
+				}
+
+				return api.OnResolveResult{
+					Path:       result.Path,
+					External:   result.External,
+					Namespace:  result.Namespace,
+					PluginData: result.PluginData,
+				}, nil
+			})
+		},
+	}
+}
@@ -14,14 +14,25 @@ import (
 	"sync"
 
 	"github.com/evanw/esbuild/pkg/api"
+	"micromachine.dev/cmd-utils/lib/utils"
 )
 
 const requiredNodeBuiltInNamespace = "node-built-in-modules"
 const requiredUnenvAliasNamespace = "required-unenv-alias"
 
+// unenvResolved marks the resolutions of aliases started by
+// NodeJsHybridPlugin, so that an alias such as `"debug": "debug"` does not
+// loop.
+type unenvResolved struct{}
+
 type NodeJsHybridPlugin struct {
-	BasePath       string
-	PackageManager string
+	BasePath string
+	// Offline fails the build instead of downloading unenv when the project
+	// does not install it and it is not cached.
+	Offline bool
+
+	// unenvDir is the directory unenv resolves from, see locateUnenv.
+	unenvDir string
 }
 
 var nodeModulesReStr = fmt.Sprintf(`^(node:)?(%s)$`, strings.Join(getNodeJsBuiltinModules(), "|"))
@@ -35,7 +46,7 @@ func (p *NodeJsHybridPlugin) New(compatibilityDate string, compatibilityFlags []
 				return
 			}
 
-			cfg, err := p.getUnenvConfig(p.BasePath, compatibilityDate, compatibilityFlags)
+			cfg, err := p.getUnenvConfig(compatibilityDate, compatibilityFlags)
 
 			if err != nil {
 				slog.Error(err.Error())
@@ -137,25 +148,18 @@ func (*NodeJsHybridPlugin) handleRequireCallsToNodeJSBuiltins(build api.PluginBu
  */
 
 func (p *NodeJsHybridPlugin) handleUnenvAliasedPackages(build api.PluginBuild, alias map[string]string, external []string) {
-	aliasAbsolutePaths := make(map[string]string)
-	for alias, path := range alias {
-		resolved, err := nodeResolve(p.BasePath, path)
-
-		if err != nil {
-			continue
-		}
-
-		aliasAbsolutePaths[alias] = *resolved
-	}
-
-	keys := make([]string, 0, len(aliasAbsolutePaths))
-	for k := range aliasAbsolutePaths {
+	keys := make([]string, 0, len(alias))
+	for k := range alias {
 		keys = append(keys, regexp.QuoteMeta(k))
 	}
 
 	unenvAliasReStr := fmt.Sprintf(`^(%s)$`, strings.Join(keys, "|"))
 
 	build.OnResolve(api.OnResolveOptions{Filter: unenvAliasReStr}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+		if _, ok := args.PluginData.(unenvResolved); ok {
+			return api.OnResolveResult{}, nil
+		}
+
 		unresolvedAlias := alias[args.Path]
 		if args.Kind == api.ResolveJSRequireCall &&
 			(strings.HasPrefix(unresolvedAlias, "unenv/npm/") ||
@@ -166,9 +170,28 @@ func (p *NodeJsHybridPlugin) handleUnenvAliasedPackages(build api.PluginBuild, a
 			}, nil
 		}
 
+		if slices.Contains(external, unresolvedAlias) {
+			return api.OnResolveResult{
+				Path:     unresolvedAlias,
+				External: true,
+			}, nil
+		}
+
+		// Resolve from where unenv is installed, with esbuild which also knows
+		// about Yarn Plug'n'Play installs.
+		result := build.Resolve(unresolvedAlias, api.ResolveOptions{
+			ResolveDir: p.unenvDir,
+			Kind:       args.Kind,
+			PluginData: unenvResolved{},
+		})
+		if len(result.Errors) > 0 {
+			return api.OnResolveResult{}, nil
+		}
+
 		return api.OnResolveResult{
-			Path:     aliasAbsolutePaths[args.Path],
-			External: slices.Contains(external, unresolvedAlias),
+			Path:      result.Path,
+			External:  result.External,
+			Namespace: result.Namespace,
 		}, nil
 	})
 
@@ -183,8 +206,9 @@ func (p *NodeJsHybridPlugin) handleUnenvAliasedPackages(build api.PluginBuild, a
 								);
 				`, args.Path)
 		return api.OnLoadResult{
-			Contents: &content,
-			Loader:   api.LoaderJS,
+			Contents:   &content,
+			Loader:     api.LoaderJS,
+			ResolveDir: p.unenvDir,
 		}, nil
 	})
 }
@@ -261,13 +285,31 @@ func (p *NodeJsHybridPlugin) handleNodeJSGlobals(build api.PluginBuild, inject m
 		build.InitialOptions.Inject = append(build.InitialOptions.Inject, k)
 	}
 
-	polyfillResolved, err := resolvePolyfills(p.BasePath, polyfill)
-	if err != nil {
-		slog.Error(err.Error())
-		os.Exit(2)
+	// The polyfills are imported by a single virtual module so that esbuild
+	// resolves them, in order, from where unenv is installed.
+	polyfillPath := pathResolve(p.BasePath, "_virtual_unenv_polyfills")
+	if len(polyfill) > 0 {
+		build.InitialOptions.Inject = append(build.InitialOptions.Inject, polyfillPath)
 	}
 
-	build.InitialOptions.Inject = append(build.InitialOptions.Inject, polyfillResolved...)
+	build.OnResolve(api.OnResolveOptions{Filter: "_virtual_unenv_polyfills$"}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+		return api.OnResolveResult{
+			Path: args.Path,
+		}, nil
+	})
+
+	build.OnLoad(api.OnLoadOptions{Filter: "_virtual_unenv_polyfills$"}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+		imports := make([]string, len(polyfill))
+		for i, module := range polyfill {
+			imports[i] = fmt.Sprintf(`import %q;`, module)
+		}
+
+		contents := strings.Join(imports, "\n")
+		return api.OnLoadResult{
+			Contents:   &contents,
+			ResolveDir: p.unenvDir,
+		}, nil
+	})
 
 	build.OnResolve(api.OnResolveOptions{Filter: unenvVirtualModuleReStr}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
 		return api.OnResolveResult{
@@ -312,7 +354,8 @@ func (p *NodeJsHybridPlugin) handleNodeJSGlobals(build api.PluginBuild, inject m
 			strings.Join(injectContent, "\n"))
 
 		return api.OnLoadResult{
-			Contents: &contents,
+			Contents:   &contents,
+			ResolveDir: p.unenvDir,
 		}, nil
 	})
 
@@ -328,37 +371,6 @@ func pathResolve(paths ...string) string {
 	return abs
 }
 
-func nodeResolve(dir, module string) (*string, error) {
-	cmd := exec.Command("node", "-e", fmt.Sprintf(`console.log(require.resolve("%s"))`, module))
-	cmd.Dir = dir
-	output, err := cmd.Output()
-
-	if err != nil {
-		return nil, fmt.Errorf("require.resolve failed: %w", err)
-	}
-
-	resolved := strings.TrimSpace(string(output))
-	return &resolved, nil
-}
-
-func resolvePolyfills(dir string, modules []string) ([]string, error) {
-	modulesJSON, _ := json.Marshal(modules)
-	script := fmt.Sprintf(`console.log(JSON.stringify(%s.map(m => require.resolve(m))))`, modulesJSON)
-
-	cmd := exec.Command("node", "-e", script)
-	cmd.Dir = dir
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("require.resolve failed: %w", err)
-	}
-
-	var resolved []string
-	if err := json.Unmarshal(output, &resolved); err != nil {
-		return nil, err
-	}
-	return resolved, nil
-}
-
 type unenvConfig struct {
 	Alias    map[string]string `json:"alias"`
 	Inject   map[string]any    `json:"inject"` // adjust type as needed
@@ -366,13 +378,12 @@ type unenvConfig struct {
 	Polyfill []string          `json:"polyfill"`
 }
 
-func (p *NodeJsHybridPlugin) getUnenvConfig(dir string, compatibilityDate string, compatibilityFlags []string) (*unenvConfig, error) {
-
-	cmd := exec.Command(p.PackageManager, "install", "-D", "unenv@2.0.0-rc.24", "@cloudflare/unenv-preset@latest")
-	cmd.Dir = dir
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to install unenv: %w", err)
+func (p *NodeJsHybridPlugin) getUnenvConfig(compatibilityDate string, compatibilityFlags []string) (*unenvConfig, error) {
+	dir, err := p.locateUnenv()
+	if err != nil {
+		return nil, err
 	}
+	p.unenvDir = dir
 
 	flagsJSON, _ := json.Marshal(compatibilityFlags)
 
@@ -399,8 +410,7 @@ func (p *NodeJsHybridPlugin) getUnenvConfig(dir string, compatibilityDate string
 
 	script = fmt.Sprintf(script, compatibilityDate, flagsJSON)
 
-	cmd = exec.Command("node", "--input-type=module", "-e", script)
-	cmd.Dir = dir
+	cmd := utils.NodeCommand(dir, "--input-type=module", "-e", script)
 	output, err := cmd.Output()
 	if err != nil {
 		var exitErr *exec.ExitError
@@ -0,0 +1,110 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// The versions of unenv and of the Cloudflare preset cached when the project
+// does not install them.
+const (
+	unenvVersion       = "2.0.0-rc.24"
+	unenvPresetVersion = "2.7.10"
+)
+
+// unenvPackages are the packages the unenv configuration is computed with.
+var unenvPackages = []string{"unenv", "@cloudflare/unenv-preset"}
+
+// locateUnenv returns the directory unenv and `@cloudflare/unenv-preset`
+// resolve from: the project when it installs them, or else the copy pinned to
+// unenvVersion and unenvPresetVersion in the micromachine cache, which is
+// downloaded unless the plugin is offline.
+func (p *NodeJsHybridPlugin) locateUnenv() (string, error) {
+	if isUnenvInstalled(p.BasePath) {
+		return p.BasePath, nil
+	}
+
+	cacheDir, err := utils.CacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not locate the micromachine cache: %w", err)
+	}
+
+	dir := filepath.Join(cacheDir, "unenv", unenvVersion+"_"+unenvPresetVersion)
+	if isUnenvCached(dir) {
+		return dir, nil
+	}
+
+	if p.Offline {
+		return "", fmt.Errorf("`unenv` and `@cloudflare/unenv-preset` are needed by `nodejs_compat` but are neither installed in the project nor cached in `%s`; add them to the dev dependencies, or build once without --offline to cache them", dir)
+	}
+
+	utils.LogWithColor(utils.Muted, fmt.Sprintf("Caching `unenv@%s` and `@cloudflare/unenv-preset@%s` in `%s`...", unenvVersion, unenvPresetVersion, dir))
+	if err := cacheUnenv(dir); err != nil {
+		return "", fmt.Errorf("could not cache unenv: %w", err)
+	}
+
+	return dir, nil
+}
+
+// isUnenvInstalled reports whether node imports the unenv packages from dir,
+// whether they are in node_modules or in a Yarn Plug'n'Play install.
+func isUnenvInstalled(dir string) bool {
+	script := ""
+	for _, pkg := range unenvPackages {
+		script += fmt.Sprintf("await import(%q);\n", pkg)
+	}
+
+	return utils.NodeCommand(dir, "--input-type=module", "-e", script).Run() == nil
+}
+
+func isUnenvCached(dir string) bool {
+	for _, pkg := range unenvPackages {
+		if _, err := os.Stat(filepath.Join(dir, "node_modules", pkg, "package.json")); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// cacheUnenv installs the pinned unenv packages in dir with npm. They are
+// installed next to dir first so that concurrent builds never see a partial
+// copy.
+func cacheUnenv(dir string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), ".install-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"private": true}`), 0644); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("npm", "install", "--no-package-lock", "--no-audit", "--no-fund",
+		"unenv@"+unenvVersion, "@cloudflare/unenv-preset@"+unenvPresetVersion)
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("npm install failed: %w\n%s", err, output)
+	}
+
+	if err := os.Rename(tmpDir, dir); err != nil {
+		// Another build cached it first.
+		if isUnenvCached(dir) {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
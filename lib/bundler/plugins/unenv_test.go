@@ -0,0 +1,57 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+func TestLocateUnenv(t *testing.T) {
+	tests := []struct {
+		name   string
+		cached []string
+		found  bool
+	}{
+		{"Cached", unenvPackages, true},
+		{"Partially cached", []string{"unenv"}, false},
+		{"Not cached", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheDir := t.TempDir()
+			t.Setenv(utils.CacheDirEnv, cacheDir)
+
+			dir := filepath.Join(cacheDir, "unenv", unenvVersion+"_"+unenvPresetVersion)
+			for _, pkg := range tt.cached {
+				pkgDir := filepath.Join(dir, "node_modules", pkg)
+				if err := os.MkdirAll(pkgDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(pkgDir, "package.json"), []byte(`{}`), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			p := &NodeJsHybridPlugin{BasePath: t.TempDir(), Offline: true}
+
+			got, err := p.locateUnenv()
+			if !tt.found {
+				if err == nil || !strings.Contains(err.Error(), "--offline") {
+					t.Errorf("Expected an offline error, got %q, %v", got, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("locateUnenv() error = %v", err)
+			}
+			if got != dir {
+				t.Errorf("locateUnenv() = %s, want %s", got, dir)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package bundler
+
+import (
+	"cmp"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// ErrSizeBudgetExceeded is returned by Pack when the compressed worker is
+// larger than the size budget.
+var ErrSizeBudgetExceeded = errors.New("worker exceeds the size budget")
+
+// FileSize is the raw and gzip size of a file of the packed worker.
+type FileSize struct {
+	Path string
+	Raw  int64
+	Gzip int64
+}
+
+// MeasureModuleSize returns the raw and gzip size of every file uploaded with
+// the worker, i.e. every file of GetModuleDir except source maps.
+func (b *Bundle) MeasureModuleSize() ([]FileSize, error) {
+	moduleDir := filepath.Join(b.RootDir, b.GetModuleDir())
+
+	var sizes []FileSize
+	err := filepath.WalkDir(moduleDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || strings.HasSuffix(path, ".map") {
+			return nil
+		}
+
+		size, err := measureFile(path)
+		if err != nil {
+			return err
+		}
+
+		size.Path, _ = filepath.Rel(moduleDir, path)
+		sizes = append(sizes, *size)
+		return nil
+	})
+
+	slices.SortFunc(sizes, func(a, b FileSize) int {
+		return cmp.Compare(b.Gzip, a.Gzip)
+	})
+
+	return sizes, err
+}
+
+func measureFile(path string) (*FileSize, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	counter := &byteCounter{}
+	writer := gzip.NewWriter(counter)
+
+	raw, err := io.Copy(writer, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return &FileSize{Raw: raw, Gzip: counter.n}, nil
+}
+
+type byteCounter struct {
+	n int64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// checkSizeBudget prints the size of the packed worker and compares its gzip
+// size against the budget.
+func (b *Bundle) checkSizeBudget() error {
+	sizes, err := b.MeasureModuleSize()
+	if err != nil {
+		return fmt.Errorf("could not measure worker size: %w", err)
+	}
+
+	var raw, gzipped int64
+	utils.LogWithColor(utils.Default, fmt.Sprintf("%-40s %12s %12s", "File", "Size", "Gzip"))
+	for _, size := range sizes {
+		raw += size.Raw
+		gzipped += size.Gzip
+		utils.LogWithColor(utils.Muted, fmt.Sprintf("%-40s %12s %12s", filepath.ToSlash(size.Path), utils.FormatBytes(size.Raw), utils.FormatBytes(size.Gzip)))
+	}
+	utils.LogWithColor(utils.Default, fmt.Sprintf("%-40s %12s %12s", "Total", utils.FormatBytes(raw), utils.FormatBytes(gzipped)))
+
+	switch {
+	case gzipped > b.SizeBudget.Max:
+		utils.LogWithColor(utils.Fail, fmt.Sprintf("✗ The compressed worker (%s) exceeds the size budget of %s", utils.FormatBytes(gzipped), utils.FormatBytes(b.SizeBudget.Max)))
+		return ErrSizeBudgetExceeded
+	case b.SizeBudget.Warn > 0 && gzipped > b.SizeBudget.Warn:
+		utils.LogWithColor(utils.Warning, fmt.Sprintf("The compressed worker (%s) is above the warning threshold of %s (budget: %s)", utils.FormatBytes(gzipped), utils.FormatBytes(b.SizeBudget.Warn), utils.FormatBytes(b.SizeBudget.Max)))
+	default:
+		utils.LogWithColor(utils.Success, fmt.Sprintf("✓ The compressed worker (%s) is within the size budget of %s", utils.FormatBytes(gzipped), utils.FormatBytes(b.SizeBudget.Max)))
+	}
+
+	return nil
+}
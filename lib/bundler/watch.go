@@ -0,0 +1,329 @@
+package bundler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// pollInterval is how often the assets directory and the wrangler file are
+// checked for changes. esbuild watches the module graph on its own.
+const pollInterval = 300 * time.Millisecond
+
+// Watch keeps an esbuild context alive and rebuilds the worker whenever one of
+// its sources changes. Assets are re-synced file by file and the wrangler
+// configuration is re-read when it changes on disk. Watch runs no framework
+// build, so a custom `[build]` command of the wrangler configuration is gated
+// as for a worker without a framework: unless a build script is set, it runs
+// first and again whenever its `watch_dir` changes. Watch blocks until ctx is
+// cancelled.
+func (b *Bundle) Watch(ctx context.Context) error {
+	absDir, err := filepath.Abs(b.RootDir)
+	if err != nil {
+		slog.Error(fmt.Sprintf("%v", err))
+		return fmt.Errorf("could not resolve absolute path: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Join(absDir, b.GetOutputDir()), 0755)
+	if err != nil {
+		slog.Error(fmt.Sprintf("%v", err))
+		return fmt.Errorf("could not create output directory: %w", err)
+	}
+
+	wranglerPath, err := utils.FindWranglerFile(&b.RootDir)
+	if err != nil {
+		slog.Error(fmt.Sprintf("%v", err))
+		return err
+	}
+	wranglerStamp, _ := statFile(wranglerPath)
+
+	var customBuild *customBuildWatcher
+	if b.RunsCustomBuild() {
+		if err := b.RunCustomBuild(); err != nil {
+			return err
+		}
+		customBuild = b.newCustomBuildWatcher(absDir)
+	}
+
+	session, err := b.startWatchSession(absDir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		session.dispose()
+	}()
+
+	utils.LogWithColor(utils.Cyan, "Watching for changes... (press Ctrl+C to stop)")
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if stamp, err := statFile(wranglerPath); err == nil && stamp != wranglerStamp {
+			wranglerStamp = stamp
+			utils.LogWithColor(utils.Default, fmt.Sprintf("Detected changes in `%s`, reloading...", filepath.Base(wranglerPath)))
+
+			if err := b.reloadWranglerConfig(); err != nil {
+				slog.Error(fmt.Sprintf("Could not reload wrangler configuration: %v", err))
+				continue
+			}
+
+			next, err := b.startWatchSession(absDir)
+			if err != nil {
+				// Keep serving the previous build until the configuration is fixed.
+				continue
+			}
+
+			session.dispose()
+			session = next
+			customBuild = nil
+			if b.RunsCustomBuild() {
+				customBuild = b.newCustomBuildWatcher(absDir)
+			}
+			continue
+		}
+
+		if customBuild != nil && customBuild.changed() {
+			utils.LogWithColor(utils.Default, fmt.Sprintf("Detected changes in `%s`, rebuilding...", customBuild.relDir(absDir)))
+			if err := b.RunCustomBuild(); err != nil {
+				// Keep serving the previous build until the sources are fixed.
+				continue
+			}
+			// Skip the changes of the build itself.
+			customBuild.changed()
+		}
+
+		session.sync()
+	}
+}
+
+// reloadWranglerConfig re-reads the wrangler file and carries the entrypoint
+// and assets directory over, unless they were overridden by the user.
+func (b *Bundle) reloadWranglerConfig() error {
+	wrangler, err := utils.DetectWranglerFile[utils.WranglerConfig](&b.RootDir)
+	if err != nil {
+		return err
+	}
+	wrangler = wrangler.ForEnvironment(b.Environment)
+
+	previous := b.WranglerConfig
+	if previous == nil || b.ModulePath == previous.Main {
+		b.ModulePath = wrangler.Main
+	}
+
+	if previous == nil || previous.Assets == nil || b.AssetPath == previous.Assets.Directory {
+		b.AssetPath = ""
+		if wrangler.Assets != nil {
+			b.AssetPath = wrangler.Assets.Directory
+		}
+	}
+
+	b.WranglerConfig = wrangler
+	return nil
+}
+
+type watchSession struct {
+	context api.BuildContext
+	syncers []*dirSync
+}
+
+func (b *Bundle) startWatchSession(absDir string) (*watchSession, error) {
+	modulePath, err := b.resolveModulePath()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &watchSession{}
+
+	if b.shouldBundle() {
+		options, err := b.buildOptions(absDir, modulePath)
+		if err != nil {
+			return nil, err
+		}
+
+		options.Plugins = append(options.Plugins, watchLoggerPlugin())
+
+		buildCtx, ctxErr := api.Context(*options)
+		if ctxErr != nil {
+			for _, err := range ctxErr.Errors {
+				slog.Error("" + err.Text)
+			}
+			return nil, fmt.Errorf("could not create build context with %d error(s)", len(ctxErr.Errors))
+		}
+
+		if err := buildCtx.Watch(api.WatchOptions{}); err != nil {
+			buildCtx.Dispose()
+			slog.Error(fmt.Sprintf("%v", err))
+			return nil, fmt.Errorf("could not start watching: %w", err)
+		}
+
+		session.context = buildCtx
+	} else {
+		session.syncers = append(session.syncers, &dirSync{
+			name: "module",
+			src:  filepath.Dir(filepath.Join(absDir, modulePath)),
+			dst:  filepath.Join(absDir, b.GetModuleDir()),
+		})
+	}
+
+	if dir, ignore := b.assetSource(absDir, modulePath); dir != "" {
+		session.syncers = append(session.syncers, &dirSync{
+			name:   "asset",
+			src:    dir,
+			dst:    b.GetAssetDir(),
+			ignore: ignore,
+		})
+	}
+
+	session.sync()
+
+	return session, nil
+}
+
+func (s *watchSession) sync() {
+	for _, syncer := range s.syncers {
+		if err := syncer.sync(); err != nil {
+			slog.Error(fmt.Sprintf("Could not sync %s files: %v", syncer.name, err))
+		}
+	}
+}
+
+func (s *watchSession) dispose() {
+	if s.context != nil {
+		s.context.Dispose()
+	}
+}
+
+func watchLoggerPlugin() api.Plugin {
+	return api.Plugin{
+		Name: "micromachine-watch",
+		Setup: func(build api.PluginBuild) {
+			var start time.Time
+
+			build.OnStart(func() (api.OnStartResult, error) {
+				start = time.Now()
+				return api.OnStartResult{}, nil
+			})
+
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					for _, err := range result.Errors {
+						slog.Error("" + err.Text)
+					}
+					slog.Error(fmt.Sprintf("Rebuild failed with %d error(s)", len(result.Errors)))
+					return api.OnEndResult{}, nil
+				}
+
+				utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Worker rebuilt in %s", time.Since(start)))
+				return api.OnEndResult{}, nil
+			})
+		},
+	}
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func statFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+
+	return fileStamp{size: info.Size(), modTime: info.ModTime()}, nil
+}
+
+// dirSync mirrors src into dst, copying only the files whose size or
+// modification time changed since the previous sync and removing the ones
+// that disappeared.
+type dirSync struct {
+	name   string
+	src    string
+	dst    string
+	ignore []string
+	seen   map[string]fileStamp
+}
+
+func (s *dirSync) sync() error {
+	if _, err := os.Stat(s.src); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	initial := s.seen == nil
+	current := make(map[string]fileStamp, len(s.seen))
+	changed := 0
+
+	err := filepath.WalkDir(s.src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(s.src, path)
+		if isIgnored(s.src, rel, s.ignore) || d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		stamp := fileStamp{size: info.Size(), modTime: info.ModTime()}
+		current[rel] = stamp
+
+		if previous, ok := s.seen[rel]; ok && previous == stamp {
+			return nil
+		}
+
+		target := filepath.Join(s.dst, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		changed++
+		return os.WriteFile(target, data, info.Mode())
+	})
+	if err != nil {
+		return err
+	}
+
+	for rel := range s.seen {
+		if _, ok := current[rel]; ok {
+			continue
+		}
+
+		changed++
+		if err := os.Remove(filepath.Join(s.dst, rel)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	s.seen = current
+
+	if changed > 0 && !initial {
+		utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Synced %d changed %s file(s)", changed, s.name))
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package bundler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+func TestAssetSyncAssetsIgnore(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"public/index.html":    "<h1>Hello</h1>",
+		"public/secret.txt":    "secret",
+		"public/.assetsignore": "secret.txt\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := &Bundle{
+		RootDir:        dir,
+		ModulePath:     "src/index.js",
+		AssetPath:      "public",
+		WranglerConfig: &utils.WranglerConfig{Assets: &utils.AssetsConfig{Directory: "public"}},
+	}
+
+	src, ignore := b.assetSource(dir, b.ModulePath)
+	syncer := &dirSync{name: "asset", src: src, dst: filepath.Join(dir, "out"), ignore: ignore}
+	if err := syncer.sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "out", "index.html")); err != nil {
+		t.Errorf("Expected index.html to be synced: %v", err)
+	}
+	for _, name := range []string{"secret.txt", ".assetsignore"} {
+		if _, err := os.Stat(filepath.Join(dir, "out", name)); err == nil {
+			t.Errorf("Expected %s to be ignored as in the build", name)
+		}
+	}
+}
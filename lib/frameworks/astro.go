@@ -0,0 +1,103 @@
+package frameworks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"micromachine.dev/cmd-utils/lib/bundler"
+)
+
+// astroAdapterImportPattern matches the default import of the Cloudflare
+// adapter, capturing its binding, e.g. `cloudflare`.
+var astroAdapterImportPattern = regexp.MustCompile("\\bimport\\s+([\\w$]+)\\s+from\\s+[\"'`]@astrojs/cloudflare[\"'`]")
+
+// astroWorkerDir is the directory `@astrojs/cloudflare` writes the worker
+// to, inside the `dist` directory holding the client assets.
+const astroWorkerDir = "dist/_worker.js"
+
+// Astro builds Astro apps with the `@astrojs/cloudflare` adapter. The worker
+// is packed from `dist/_worker.js` and the rest of `dist`, minus the files
+// listed in `dist/.assetsignore`, becomes the assets.
+type Astro struct {
+	scriptAdapter
+}
+
+func (a *Astro) Name() string {
+	return "astro"
+}
+
+func (a *Astro) Detect(rootDir string) bool {
+	return a.Explain(rootDir) != ""
+}
+
+func (a *Astro) Explain(rootDir string) string {
+	return detectEvidence(rootDir, "astro.config", "astro")
+}
+
+// Build checks that the Cloudflare adapter is configured, then runs the
+// `build` script, or the one given with `--script`.
+func (a *Astro) Build(b *bundler.Bundle) error {
+	if !hasDependency(b.RootDir, "@astrojs/cloudflare") {
+		return errors.New("`@astrojs/cloudflare` is not installed, add it with `astro add cloudflare`")
+	}
+
+	path := findConfigFile(b.RootDir, "astro.config")
+	if path == "" {
+		return errors.New("no astro.config found")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if !hasAstroCloudflareAdapter(data) {
+		return fmt.Errorf("`%s` does not set the `@astrojs/cloudflare` adapter, add it with `astro add cloudflare`", filepath.Base(path))
+	}
+
+	return runBuildScript(b)
+}
+
+// hasAstroCloudflareAdapter reports whether an astro.config sets the adapter
+// to a call of the binding `@astrojs/cloudflare` is imported as, e.g.
+// `adapter: cloudflare()`.
+func hasAstroCloudflareAdapter(data []byte) bool {
+	match := astroAdapterImportPattern.FindSubmatch(data)
+	if match == nil {
+		return false
+	}
+
+	adapter := regexp.MustCompile(`\badapter\s*:\s*` + regexp.QuoteMeta(string(match[1])) + `\s*\(`)
+	return adapter.Match(data)
+}
+
+func (a *Astro) LocateServerOutput(b *bundler.Bundle) (string, error) {
+	return astroWorkerDir, nil
+}
+
+func (a *Astro) LocateAssets(b *bundler.Bundle) (string, error) {
+	return "dist", nil
+}
+
+// Output is the worker and the assets Astro builds, whatever `main` and
+// `assets.directory` are set to in wrangler.
+func (a *Astro) Output(b *bundler.Bundle) bundler.FrameworkOutput {
+	return bundler.FrameworkOutput{
+		ModulePath: filepath.Join(astroWorkerDir, "index.js"),
+		AssetPath:  "dist",
+	}
+}
+
+// PostProcess points the bundle at the Output of the build.
+func (a *Astro) PostProcess(b *bundler.Bundle) error {
+	output := a.Output(b)
+	if _, err := os.Stat(filepath.Join(b.RootDir, output.ModulePath)); err != nil {
+		return fmt.Errorf("the build did not write `%s`, check that `output` is `server` or that pages opt out of prerendering", output.ModulePath)
+	}
+
+	b.UseFrameworkOutput(output)
+	return nil
+}
@@ -0,0 +1,92 @@
+package frameworks
+
+import (
+	"strings"
+	"testing"
+
+	"micromachine.dev/cmd-utils/lib/bundler"
+)
+
+func TestAstroBuildChecksAdapter(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			"Missing dependency",
+			map[string]string{
+				"package.json":     `{"dependencies": {"astro": "^5.0.0"}}`,
+				"astro.config.mjs": "export default defineConfig({})",
+			},
+			"`@astrojs/cloudflare` is not installed",
+		},
+		{
+			"Adapter not configured",
+			map[string]string{
+				"package.json":     `{"dependencies": {"astro": "^5.0.0", "@astrojs/cloudflare": "^12.0.0"}}`,
+				"astro.config.mjs": "import node from '@astrojs/node';\nexport default defineConfig({ adapter: node() })",
+			},
+			"does not set the `@astrojs/cloudflare` adapter",
+		},
+		{
+			"Adapter only mentioned",
+			map[string]string{
+				"package.json":     `{"dependencies": {"astro": "^5.0.0", "@astrojs/cloudflare": "^12.0.0"}}`,
+				"astro.config.mjs": "import node from '@astrojs/node';\n// TODO: switch the adapter to @astrojs/cloudflare\nexport default defineConfig({ adapter: node() })",
+			},
+			"does not set the `@astrojs/cloudflare` adapter",
+		},
+		{
+			"Adapter imported but not set",
+			map[string]string{
+				"package.json":     `{"dependencies": {"astro": "^5.0.0", "@astrojs/cloudflare": "^12.0.0"}}`,
+				"astro.config.mjs": "import cloudflare from '@astrojs/cloudflare';\nimport node from '@astrojs/node';\nexport default defineConfig({ adapter: node() })",
+			},
+			"does not set the `@astrojs/cloudflare` adapter",
+		},
+		{
+			"Adapter imported under another name",
+			map[string]string{
+				"package.json":     `{"dependencies": {"astro": "^5.0.0", "@astrojs/cloudflare": "^12.0.0"}}`,
+				"astro.config.mjs": "import workers from \"@astrojs/cloudflare\";\nexport default defineConfig({\n  adapter: workers({ imageService: 'cloudflare' }),\n})",
+			},
+			"no `build` script",
+		},
+		{
+			"No build script",
+			map[string]string{
+				"package.json":     `{"dependencies": {"astro": "^5.0.0", "@astrojs/cloudflare": "^12.0.0"}}`,
+				"astro.config.mjs": "import cloudflare from '@astrojs/cloudflare';\nexport default defineConfig({ adapter: cloudflare() })",
+			},
+			"no `build` script",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeProject(t, tt.files)
+
+			err := (&Astro{}).Build(&bundler.Bundle{RootDir: dir})
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error to contain %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestAstroPostProcess(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"dist/_worker.js/index.js": "export default {}",
+		"dist/index.html":          "",
+	})
+
+	b := &bundler.Bundle{RootDir: dir, ModulePath: ".micromachine/worker/index.js", AssetPath: ".micromachine/assets"}
+	if err := (&Astro{}).PostProcess(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.ModulePath != "dist/_worker.js/index.js" || b.AssetPath != "dist" {
+		t.Errorf("Expected the bundle to point at Astro's output, got %s and %s", b.ModulePath, b.AssetPath)
+	}
+}
@@ -0,0 +1,19 @@
+package frameworks
+
+// Generic builds any project with its build script and relies on the wrangler
+// configuration to find the output.
+type Generic struct {
+	scriptAdapter
+}
+
+func (a *Generic) Name() string {
+	return "generic"
+}
+
+func (a *Generic) Detect(rootDir string) bool {
+	return true
+}
+
+func (a *Generic) Explain(rootDir string) string {
+	return "no other framework matched"
+}
@@ -0,0 +1,65 @@
+package frameworks
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"micromachine.dev/cmd-utils/lib/bundler"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// NextJS builds Next.js apps with the OpenNext Cloudflare adapter.
+type NextJS struct{}
+
+func (a *NextJS) Name() string {
+	return "nextjs"
+}
+
+func (a *NextJS) Detect(rootDir string) bool {
+	return utils.IsNextJS(rootDir)
+}
+
+func (a *NextJS) Explain(rootDir string) string {
+	if path := findConfigFile(rootDir, "next.config"); path != "" {
+		return "found `" + filepath.Base(path) + "`"
+	}
+	if a.Detect(rootDir) {
+		return "found `.next`"
+	}
+
+	return ""
+}
+
+func (a *NextJS) Build(b *bundler.Bundle) error {
+	start := time.Now()
+	utils.LogWithColor(utils.Default, "Running `opennextjs-cloudflare build`...")
+
+	if !hasDependency(b.RootDir, "@opennextjs/cloudflare") {
+		if err := b.AddDevDependencies("@opennextjs/cloudflare"); err != nil {
+			return err
+		}
+	}
+
+	name, args := b.PackageManager.Exec("opennextjs-cloudflare", "build")
+	err := b.RunCommand(name, args...)
+	if err != nil {
+		return fmt.Errorf("opennextjs-cloudflare build failed: %w", err)
+	}
+
+	elapsed := time.Since(start)
+	utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Completed `opennextjs-cloudflare build` in %s", elapsed))
+	return nil
+}
+
+func (a *NextJS) LocateServerOutput(b *bundler.Bundle) (string, error) {
+	return ".open-next", nil
+}
+
+func (a *NextJS) LocateAssets(b *bundler.Bundle) (string, error) {
+	return ".open-next/assets", nil
+}
+
+func (a *NextJS) PostProcess(b *bundler.Bundle) error {
+	return nil
+}
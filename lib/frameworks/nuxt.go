@@ -0,0 +1,130 @@
+package frameworks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"micromachine.dev/cmd-utils/lib/bundler"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// nitroBlockPattern matches the start of the `nitro` settings of nuxt.config,
+// and nitroPresetPattern the `preset` option inside them.
+var (
+	nitroBlockPattern  = regexp.MustCompile(`\bnitro\s*:\s*\{`)
+	nitroPresetPattern = regexp.MustCompile("\\bpreset\\s*:\\s*[\"'`]([\\w-]+)[\"'`]")
+)
+
+// Nuxt builds Nuxt apps with the `cloudflare_module` Nitro preset, whose
+// server lands in `.output/server` and assets in `.output/public`.
+type Nuxt struct {
+	scriptAdapter
+}
+
+func (a *Nuxt) Name() string {
+	return "nuxt"
+}
+
+func (a *Nuxt) Detect(rootDir string) bool {
+	return a.Explain(rootDir) != ""
+}
+
+func (a *Nuxt) Explain(rootDir string) string {
+	return detectEvidence(rootDir, "nuxt.config", "nuxt")
+}
+
+// Build runs the `build` script, or the one given with `--script`, making
+// sure Nitro targets Cloudflare Workers.
+func (a *Nuxt) Build(b *bundler.Bundle) error {
+	preset, source := nitroPreset(b.RootDir)
+
+	switch {
+	case preset == "":
+		utils.LogWithColor(utils.Muted, "No Nitro preset configured, building with `cloudflare_module`")
+		b.CommandEnv = append(b.CommandEnv, "NITRO_PRESET=cloudflare_module")
+	case !isCloudflareModulePreset(preset):
+		return fmt.Errorf("%s sets the `%s` Nitro preset, micromachine needs `cloudflare_module`", source, preset)
+	}
+
+	return runBuildScript(b)
+}
+
+func (a *Nuxt) LocateServerOutput(b *bundler.Bundle) (string, error) {
+	return ".output/server", nil
+}
+
+func (a *Nuxt) LocateAssets(b *bundler.Bundle) (string, error) {
+	return ".output/public", nil
+}
+
+// PostProcess reads the `wrangler.json` Nitro generates with
+// `nitro.cloudflare.deployConfig`.
+func (a *Nuxt) PostProcess(b *bundler.Bundle) error {
+	return b.LoadBuildWranglerConfig()
+}
+
+// nitroPreset returns the Nitro preset selected by the NITRO_PRESET variable
+// or by nuxt.config, along with where it was found.
+func nitroPreset(rootDir string) (string, string) {
+	if preset := os.Getenv("NITRO_PRESET"); preset != "" {
+		return preset, "NITRO_PRESET"
+	}
+
+	path := findConfigFile(rootDir, "nuxt.config")
+	if path == "" {
+		return "", ""
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", ""
+	}
+
+	if m := nitroPresetPattern.FindSubmatch(nitroBlock(data)); m != nil {
+		return string(m[1]), "`" + filepath.Base(path) + "`"
+	}
+
+	return "", ""
+}
+
+// nitroBlock returns the object of the `nitro` settings of nuxt.config, so
+// that the `preset` options of other modules, e.g. `colorMode`, are not taken
+// for the Nitro one. It returns nil when there is none.
+func nitroBlock(data []byte) []byte {
+	loc := nitroBlockPattern.FindIndex(data)
+	if loc == nil {
+		return nil
+	}
+
+	start := loc[1] - 1
+	depth := 0
+	var quote byte
+	for i := start; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return data[start : i+1]
+			}
+		}
+	}
+
+	return data[start:]
+}
+
+func isCloudflareModulePreset(preset string) bool {
+	return preset == "cloudflare_module" || preset == "cloudflare-module"
+}
@@ -0,0 +1,39 @@
+package frameworks
+
+import "testing"
+
+func TestNitroPreset(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{"Cloudflare module", "export default defineNuxtConfig({\n  nitro: {\n    preset: \"cloudflare_module\",\n  },\n})\n", "cloudflare_module"},
+		{"Other preset", "export default defineNuxtConfig({ nitro: { preset: 'node-server' } })\n", "node-server"},
+		{"No preset", "export default defineNuxtConfig({ devtools: { enabled: true } })\n", ""},
+		{"Preset of another module", "export default defineNuxtConfig({\n  colorMode: { preset: 'dark' },\n  nitro: { compressPublicAssets: true },\n})\n", ""},
+		{"Preset of another module after nitro", "export default defineNuxtConfig({\n  nitro: { cloudflare: { deployConfig: true } },\n  image: { preset: \"avatar\" },\n})\n", ""},
+		{"Nitro preset after another module", "export default defineNuxtConfig({\n  ui: { preset: 'x' },\n  nitro: {\n    cloudflare: { nodeCompat: true },\n    preset: 'cloudflare-module',\n  },\n})\n", "cloudflare-module"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NITRO_PRESET", "")
+			dir := writeProject(t, map[string]string{"nuxt.config.ts": tt.config})
+
+			if got, _ := nitroPreset(dir); got != tt.expected {
+				t.Errorf("Expected preset to be %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("Environment", func(t *testing.T) {
+		t.Setenv("NITRO_PRESET", "cloudflare-module")
+		dir := writeProject(t, map[string]string{"nuxt.config.ts": "export default defineNuxtConfig({ nitro: { preset: 'node-server' } })\n"})
+
+		preset, source := nitroPreset(dir)
+		if !isCloudflareModulePreset(preset) || source != "NITRO_PRESET" {
+			t.Errorf("Expected NITRO_PRESET to take precedence, got %q from %s", preset, source)
+		}
+	})
+}
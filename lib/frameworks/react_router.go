@@ -0,0 +1,61 @@
+package frameworks
+
+import (
+	"errors"
+
+	"micromachine.dev/cmd-utils/lib/bundler"
+)
+
+// ReactRouter builds React Router v7 framework-mode apps, and Remix apps on
+// Vite, with `@cloudflare/vite-plugin`. The plugin writes the server to
+// `build/server`, along with a wrangler.json pointing at its entry, and the
+// client assets to `build/client`.
+type ReactRouter struct {
+	scriptAdapter
+}
+
+func (a *ReactRouter) Name() string {
+	return "react-router"
+}
+
+func (a *ReactRouter) Detect(rootDir string) bool {
+	return a.Explain(rootDir) != ""
+}
+
+func (a *ReactRouter) Explain(rootDir string) string {
+	return detectEvidence(rootDir, "react-router.config", "@react-router/dev", "@remix-run/dev")
+}
+
+// Build runs the `build` script, or the one given with `--script`.
+func (a *ReactRouter) Build(b *bundler.Bundle) error {
+	return runBuildScript(b)
+}
+
+// ViteEnvironment is `ssr`, the environment React Router builds the server
+// entry in.
+func (a *ReactRouter) ViteEnvironment() string {
+	return "ssr"
+}
+
+func (a *ReactRouter) LocateServerOutput(b *bundler.Bundle) (string, error) {
+	return "build/server", nil
+}
+
+func (a *ReactRouter) LocateAssets(b *bundler.Bundle) (string, error) {
+	return "build/client", nil
+}
+
+// PostProcess reads the wrangler.json `@cloudflare/vite-plugin` writes next to
+// the server entry. Without it, `build/server` holds a Node request handler
+// that cannot run on Workers.
+func (a *ReactRouter) PostProcess(b *bundler.Bundle) error {
+	if err := b.LoadBuildWranglerConfig(); err != nil {
+		return err
+	}
+
+	if b.BuildWranglerConfig == nil {
+		return errors.New("the build did not write `build/server/wrangler.json`, add `@cloudflare/vite-plugin` to the vite config or build with --vite-plugin")
+	}
+
+	return nil
+}
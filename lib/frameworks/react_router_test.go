@@ -0,0 +1,59 @@
+package frameworks
+
+import (
+	"testing"
+
+	"micromachine.dev/cmd-utils/lib/bundler"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+func TestReactRouterPostProcess(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		expectedMain string
+		expectError  bool
+	}{
+		{
+			"Generated config",
+			map[string]string{
+				"build/server/index.js":      "export default {}",
+				"build/server/wrangler.json": `{"name": "app", "main": "index.js", "no_bundle": true}`,
+			},
+			"index.js",
+			false,
+		},
+		{
+			"Without the Cloudflare plugin",
+			map[string]string{
+				"build/server/index.js": "export default {}",
+			},
+			"",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &ReactRouter{}
+			b := &bundler.Bundle{
+				RootDir:        writeProject(t, tt.files),
+				WranglerConfig: &utils.WranglerConfig{Main: "app/entry.server.ts"},
+				Framework:      adapter,
+			}
+
+			err := adapter.PostProcess(b)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Expected error %v, got %v", tt.expectError, err)
+			}
+
+			if tt.expectError {
+				return
+			}
+
+			if b.BuildWranglerConfig.Main != tt.expectedMain {
+				t.Errorf("Expected main %q, got %q", tt.expectedMain, b.BuildWranglerConfig.Main)
+			}
+		})
+	}
+}
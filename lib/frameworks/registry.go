@@ -0,0 +1,83 @@
+// Package frameworks holds the FrameworkAdapter of every framework micromachine
+// knows how to build.
+package frameworks
+
+import (
+	"fmt"
+	"slices"
+
+	"micromachine.dev/cmd-utils/lib/bundler"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// adapters are tried in order; the generic adapter matches every project and
+// always comes last.
+var adapters = []bundler.FrameworkAdapter{
+	&NextJS{},
+	&Nuxt{},
+	&Astro{},
+	&SvelteKit{},
+	&ReactRouter{},
+	&TanStackStart{},
+	&Waku{},
+}
+
+var fallback bundler.FrameworkAdapter = &Generic{}
+
+// Explainer is implemented by adapters that can tell what their Detect
+// matched in a project, e.g. "found `nuxt.config.ts`".
+type Explainer interface {
+	Explain(rootDir string) string
+}
+
+// Register adds an adapter, tried before the built-in ones.
+func Register(adapter bundler.FrameworkAdapter) {
+	adapters = slices.Insert(adapters, 0, adapter)
+}
+
+// Adapters returns the registered adapters in detection order, ending with
+// the generic one.
+func Adapters() []bundler.FrameworkAdapter {
+	return append(slices.Clone(adapters), fallback)
+}
+
+// Lookup returns the adapter with the given name.
+func Lookup(name string) (bundler.FrameworkAdapter, error) {
+	for _, adapter := range Adapters() {
+		if adapter.Name() == name {
+			return adapter, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown framework `%s`", name)
+}
+
+// Detect returns the adapter of the framework used by the project in rootDir,
+// or the generic adapter when none matches.
+func Detect(rootDir string) bundler.FrameworkAdapter {
+	adapter, _ := Match(rootDir)
+	if adapter != fallback {
+		utils.LogWithColor(utils.Default, fmt.Sprintf("Detected \033[1m`%s`\033[0m framework", adapter.Name()))
+	}
+
+	return adapter
+}
+
+// Match returns the adapter Detect would pick, without logging it, along with
+// the evidence it matched on.
+func Match(rootDir string) (bundler.FrameworkAdapter, string) {
+	for _, adapter := range Adapters() {
+		if !adapter.Detect(rootDir) {
+			continue
+		}
+
+		evidence := "`Detect` of the adapter matched"
+		if explainer, ok := adapter.(Explainer); ok {
+			evidence = explainer.Explain(rootDir)
+		}
+
+		return adapter, evidence
+	}
+
+	return fallback, ""
+}
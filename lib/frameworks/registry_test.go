@@ -0,0 +1,110 @@
+package frameworks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeProject(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{"Next.js config", map[string]string{"next.config.ts": ""}, "nextjs"},
+		{"Nuxt config", map[string]string{"nuxt.config.ts": ""}, "nuxt"},
+		{"Nuxt dependency", map[string]string{"package.json": `{"dependencies": {"nuxt": "^4.0.0"}}`}, "nuxt"},
+		{"Astro config", map[string]string{"astro.config.mjs": ""}, "astro"},
+		{"SvelteKit config", map[string]string{"svelte.config.js": ""}, "sveltekit"},
+		{"React Router config", map[string]string{"react-router.config.ts": ""}, "react-router"},
+		{"Remix dependency", map[string]string{"package.json": `{"devDependencies": {"@remix-run/dev": "^2.0.0"}}`}, "react-router"},
+		{"TanStack Start dependency", map[string]string{"package.json": `{"dependencies": {"@tanstack/react-start": "^1.0.0"}}`}, "tanstack-start"},
+		{"Waku config", map[string]string{"waku.config.ts": ""}, "waku"},
+		{"Plain worker", map[string]string{"package.json": `{"name": "worker"}`}, "generic"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeProject(t, tt.files)
+
+			if got := Detect(dir).Name(); got != tt.expected {
+				t.Errorf("Expected %s to be detected, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMatchEvidence(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		evidence string
+	}{
+		{"Config file", map[string]string{"nuxt.config.ts": "", "package.json": `{"dependencies": {"nuxt": "^4.0.0"}}`}, "found `nuxt.config.ts`"},
+		{"Dependency", map[string]string{"package.json": `{"devDependencies": {"@react-router/dev": "^7.0.0"}}`}, "package.json depends on `@react-router/dev`"},
+		{"Fallback", map[string]string{"package.json": `{"name": "worker"}`}, "no other framework matched"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, evidence := Match(writeProject(t, tt.files))
+			if evidence != tt.evidence {
+				t.Errorf("Expected evidence %q, got %q", tt.evidence, evidence)
+			}
+		})
+	}
+}
+
+type testAdapter struct {
+	Generic
+}
+
+func (a *testAdapter) Name() string {
+	return "test"
+}
+
+func (a *testAdapter) Detect(rootDir string) bool {
+	_, err := os.Stat(filepath.Join(rootDir, "test.config.js"))
+	return err == nil
+}
+
+func TestRegister(t *testing.T) {
+	registered := adapters
+	t.Cleanup(func() {
+		adapters = registered
+	})
+
+	Register(&testAdapter{})
+
+	dir := writeProject(t, map[string]string{"test.config.js": "", "nuxt.config.ts": ""})
+	if got := Detect(dir).Name(); got != "test" {
+		t.Errorf("Expected registered adapters to be tried first, got %s", got)
+	}
+
+	adapter, err := Lookup("test")
+	if err != nil || adapter.Name() != "test" {
+		t.Errorf("Expected to look up the registered adapter, got %v (%v)", adapter, err)
+	}
+
+	if _, err := Lookup("unknown"); err == nil {
+		t.Errorf("Expected an error looking up an unknown framework")
+	}
+
+}
@@ -0,0 +1,169 @@
+package frameworks
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"micromachine.dev/cmd-utils/lib/bundler"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+// scriptAdapter runs the build script given with `--script`, or else the
+// `[build]` command of the wrangler configuration, and reads the wrangler
+// configuration the build generated, if any. Adapters embed it and override
+// what their framework does differently.
+type scriptAdapter struct{}
+
+func (a *scriptAdapter) Build(b *bundler.Bundle) error {
+	if b.RunsCustomBuild() {
+		return b.RunCustomBuild()
+	}
+
+	if b.BuildScript == "" {
+		return nil
+	}
+
+	return b.RunBuildCommand()
+}
+
+func (a *scriptAdapter) LocateServerOutput(b *bundler.Bundle) (string, error) {
+	return "", nil
+}
+
+func (a *scriptAdapter) LocateAssets(b *bundler.Bundle) (string, error) {
+	return "", nil
+}
+
+func (a *scriptAdapter) PostProcess(b *bundler.Bundle) error {
+	if b.BuildScript == "" && b.CustomBuild() == nil {
+		return nil
+	}
+
+	return b.LoadBuildWranglerConfig()
+}
+
+// runBuildScript runs the script given with `--script`, defaulting to the
+// `[build]` command of the wrangler configuration, then to the `build` script
+// of package.json, or the `build` task of deno.json.
+func runBuildScript(b *bundler.Bundle) error {
+	if b.RunsCustomBuild() {
+		return b.RunCustomBuild()
+	}
+
+	if b.BuildScript == "" {
+		if b.PackageManager.Name == "deno" {
+			config, err := utils.ReadDenoConfig(b.RootDir)
+			if err != nil {
+				return err
+			}
+			if config == nil || !config.HasTask("build") {
+				return errors.New("deno.json has no `build` task, pass the one to run with --script")
+			}
+		} else {
+			packageJSON, err := utils.ReadPackageJSON(b.RootDir)
+			if err != nil {
+				return err
+			}
+			if _, ok := packageJSON.Scripts["build"]; !ok {
+				return errors.New("package.json has no `build` script, pass the one to run with --script")
+			}
+		}
+		b.BuildScript = "build"
+	}
+
+	return b.RunBuildCommand()
+}
+
+// configExtensions are the extensions of JavaScript configuration files.
+var configExtensions = []string{".js", ".mjs", ".cjs", ".ts", ".mts", ".cts"}
+
+// hasConfigFile reports whether rootDir has a `<name>.<ext>` configuration
+// file, e.g. `nuxt.config.ts`.
+func hasConfigFile(rootDir, name string) bool {
+	return findConfigFile(rootDir, name) != ""
+}
+
+// findConfigFile returns the path of the `<name>.<ext>` configuration file of
+// rootDir, or an empty string.
+func findConfigFile(rootDir, name string) string {
+	for _, ext := range configExtensions {
+		path := filepath.Join(rootDir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// stripComments removes the `//` and `/* */` comments of JavaScript source,
+// leaving strings untouched, so that commented-out code is not matched.
+func stripComments(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+
+	var quote byte
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(data) {
+				out = append(out, c)
+				i++
+				c = data[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+			continue
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+			continue
+		}
+		out = append(out, c)
+	}
+
+	return out
+}
+
+// detectEvidence returns what a project is detected on: its `<config>.<ext>`
+// configuration file or, failing that, the first of the dependencies it has.
+// It returns an empty string when there is neither.
+func detectEvidence(rootDir, config string, dependencies ...string) string {
+	if config != "" {
+		if path := findConfigFile(rootDir, config); path != "" {
+			return "found `" + filepath.Base(path) + "`"
+		}
+	}
+
+	for _, dependency := range dependencies {
+		if hasDependency(rootDir, dependency) {
+			return "package.json depends on `" + dependency + "`"
+		}
+	}
+
+	return ""
+}
+
+// hasDependency reports whether the package.json of rootDir depends on name.
+func hasDependency(rootDir, name string) bool {
+	packageJSON, err := utils.ReadPackageJSON(rootDir)
+	if err != nil {
+		return false
+	}
+
+	return packageJSON.HasDependency(name)
+}
@@ -0,0 +1,80 @@
+package frameworks
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"micromachine.dev/cmd-utils/lib/bundler"
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+func TestCustomBuild(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the custom build commands of the test need sh")
+	}
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		build    *utils.BuildConfig
+		script   bool
+		expected string
+	}{
+		{
+			"Command of the generic adapter",
+			map[string]string{"package.json": `{}`},
+			&utils.BuildConfig{Command: "echo built > out.txt"},
+			false,
+			"out.txt",
+		},
+		{
+			"Command in build.cwd",
+			map[string]string{"package.json": `{}`, "worker/Cargo.toml": ""},
+			&utils.BuildConfig{Command: "echo built > out.txt", Cwd: "worker"},
+			false,
+			"worker/out.txt",
+		},
+		{
+			"Command instead of the build script",
+			map[string]string{"package.json": `{}`},
+			&utils.BuildConfig{Command: "echo built > out.txt"},
+			true,
+			"out.txt",
+		},
+		{
+			"No command",
+			map[string]string{"package.json": `{}`},
+			&utils.BuildConfig{WatchDir: "src"},
+			false,
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bundler.Bundle{
+				RootDir:        writeProject(t, tt.files),
+				WranglerConfig: &utils.WranglerConfig{Main: "build/worker.js", Build: tt.build},
+			}
+
+			var err error
+			if tt.script {
+				err = runBuildScript(b)
+			} else {
+				err = (&Generic{}).Build(b)
+			}
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			if tt.expected == "" {
+				return
+			}
+			if _, err := os.Stat(filepath.Join(b.RootDir, tt.expected)); err != nil {
+				t.Errorf("Expected the command to write %s: %v", tt.expected, err)
+			}
+		})
+	}
+}
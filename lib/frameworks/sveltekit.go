@@ -0,0 +1,105 @@
+package frameworks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"micromachine.dev/cmd-utils/lib/bundler"
+)
+
+// svelteKitOutput is where `@sveltejs/adapter-cloudflare` writes the worker
+// and the static files.
+const svelteKitOutput = ".svelte-kit/cloudflare"
+
+var svelteAdapterPattern = regexp.MustCompile("[\"'`](@sveltejs/adapter-[\\w-]+)[\"'`]")
+
+// SvelteKit builds SvelteKit apps with `@sveltejs/adapter-cloudflare`. The
+// worker `_worker.js` imports the server output of SvelteKit and is always
+// bundled.
+type SvelteKit struct {
+	scriptAdapter
+}
+
+func (a *SvelteKit) Name() string {
+	return "sveltekit"
+}
+
+func (a *SvelteKit) Detect(rootDir string) bool {
+	return a.Explain(rootDir) != ""
+}
+
+func (a *SvelteKit) Explain(rootDir string) string {
+	return detectEvidence(rootDir, "svelte.config", "@sveltejs/kit")
+}
+
+// Build checks that svelte.config uses the Cloudflare adapter, then runs the
+// `build` script, or the one given with `--script`.
+func (a *SvelteKit) Build(b *bundler.Bundle) error {
+	adapter, err := svelteKitAdapter(b.RootDir)
+	if err != nil {
+		return err
+	}
+
+	if adapter != "@sveltejs/adapter-cloudflare" {
+		return fmt.Errorf("svelte.config uses `%s`, install `@sveltejs/adapter-cloudflare` and set it as the adapter", adapter)
+	}
+
+	if !hasDependency(b.RootDir, adapter) {
+		return fmt.Errorf("`%s` is set in svelte.config but not installed", adapter)
+	}
+
+	return runBuildScript(b)
+}
+
+func (a *SvelteKit) LocateServerOutput(b *bundler.Bundle) (string, error) {
+	return svelteKitOutput, nil
+}
+
+func (a *SvelteKit) LocateAssets(b *bundler.Bundle) (string, error) {
+	return svelteKitOutput, nil
+}
+
+// Output is `_worker.js` and the static files next to it. The worker imports
+// the server output, so it is always bundled.
+func (a *SvelteKit) Output(b *bundler.Bundle) bundler.FrameworkOutput {
+	return bundler.FrameworkOutput{
+		ModulePath:   filepath.Join(svelteKitOutput, "_worker.js"),
+		AssetPath:    svelteKitOutput,
+		ShouldBundle: true,
+	}
+}
+
+// PostProcess points the bundle at the Output of the build.
+func (a *SvelteKit) PostProcess(b *bundler.Bundle) error {
+	output := a.Output(b)
+	if _, err := os.Stat(filepath.Join(b.RootDir, output.ModulePath)); err != nil {
+		return fmt.Errorf("the build did not write `%s`, check the adapter of svelte.config", output.ModulePath)
+	}
+
+	b.UseFrameworkOutput(output)
+	return nil
+}
+
+// svelteKitAdapter returns the `@sveltejs/adapter-*` package imported by
+// svelte.config.
+func svelteKitAdapter(rootDir string) (string, error) {
+	path := findConfigFile(rootDir, "svelte.config")
+	if path == "" {
+		return "", errors.New("no svelte.config found")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	m := svelteAdapterPattern.FindSubmatch(stripComments(data))
+	if m == nil {
+		return "", fmt.Errorf("`%s` sets no adapter, install `@sveltejs/adapter-cloudflare` and set it as the adapter", filepath.Base(path))
+	}
+
+	return string(m[1]), nil
+}
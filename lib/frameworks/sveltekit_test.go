@@ -0,0 +1,38 @@
+package frameworks
+
+import (
+	"strings"
+	"testing"
+
+	"micromachine.dev/cmd-utils/lib/bundler"
+)
+
+func TestSvelteKitBuildChecksAdapter(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		deps     string
+		expected string
+	}{
+		{"Adapter auto", "import adapter from '@sveltejs/adapter-auto';\nexport default { kit: { adapter: adapter() } };", `{"@sveltejs/adapter-auto": "^6.0.0"}`, "uses `@sveltejs/adapter-auto`"},
+		{"Workers adapter", "import adapter from \"@sveltejs/adapter-cloudflare-workers\";", `{"@sveltejs/adapter-cloudflare-workers": "^2.0.0"}`, "uses `@sveltejs/adapter-cloudflare-workers`"},
+		{"No adapter", "export default { kit: {} };", `{}`, "sets no adapter"},
+		{"Commented-out adapter", "// import adapter from '@sveltejs/adapter-auto';\n/* import adapter from '@sveltejs/adapter-node'; */\nimport adapter from '@sveltejs/adapter-cloudflare';", `{"@sveltejs/adapter-cloudflare": "^7.0.0"}`, "no `build` script"},
+		{"Not installed", "import adapter from '@sveltejs/adapter-cloudflare';", `{}`, "not installed"},
+		{"No build script", "import adapter from '@sveltejs/adapter-cloudflare';", `{"@sveltejs/adapter-cloudflare": "^7.0.0"}`, "no `build` script"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeProject(t, map[string]string{
+				"svelte.config.js": tt.config,
+				"package.json":     `{"devDependencies": ` + tt.deps + `}`,
+			})
+
+			err := (&SvelteKit{}).Build(&bundler.Bundle{RootDir: dir})
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error to contain %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
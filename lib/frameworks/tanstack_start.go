@@ -0,0 +1,33 @@
+package frameworks
+
+import "micromachine.dev/cmd-utils/lib/bundler"
+
+// TanStackStart builds TanStack Start apps with `@cloudflare/vite-plugin`.
+type TanStackStart struct {
+	scriptAdapter
+}
+
+func (a *TanStackStart) Name() string {
+	return "tanstack-start"
+}
+
+func (a *TanStackStart) Detect(rootDir string) bool {
+	return a.Explain(rootDir) != ""
+}
+
+func (a *TanStackStart) Explain(rootDir string) string {
+	return detectEvidence(rootDir, "", "@tanstack/react-start", "@tanstack/solid-start")
+}
+
+// ViteEnvironment is `ssr`, where TanStack Start renders on the server.
+func (a *TanStackStart) ViteEnvironment() string {
+	return "ssr"
+}
+
+func (a *TanStackStart) LocateServerOutput(b *bundler.Bundle) (string, error) {
+	return "dist/server", nil
+}
+
+func (a *TanStackStart) LocateAssets(b *bundler.Bundle) (string, error) {
+	return "dist/client", nil
+}
@@ -0,0 +1,28 @@
+package frameworks
+
+import "micromachine.dev/cmd-utils/lib/bundler"
+
+// Waku builds Waku apps with their Cloudflare deploy target.
+type Waku struct {
+	scriptAdapter
+}
+
+func (a *Waku) Name() string {
+	return "waku"
+}
+
+func (a *Waku) Detect(rootDir string) bool {
+	return a.Explain(rootDir) != ""
+}
+
+func (a *Waku) Explain(rootDir string) string {
+	return detectEvidence(rootDir, "waku.config", "waku")
+}
+
+func (a *Waku) LocateServerOutput(b *bundler.Bundle) (string, error) {
+	return "dist/server", nil
+}
+
+func (a *Waku) LocateAssets(b *bundler.Bundle) (string, error) {
+	return "dist/public", nil
+}
@@ -0,0 +1,276 @@
+package preview
+
+import (
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+const (
+	AutoTrailingSlash  = "auto-trailing-slash"
+	ForceTrailingSlash = "force-trailing-slash"
+	DropTrailingSlash  = "drop-trailing-slash"
+	NoHTMLHandling     = "none"
+
+	SinglePageApplication = "single-page-application"
+	NotFoundPage          = "404-page"
+	NoNotFoundHandling    = "none"
+)
+
+// AssetHandler serves a static assets directory following the Workers static
+// assets semantics configured by `html_handling` and `not_found_handling`.
+type AssetHandler struct {
+	Dir              string
+	HTMLHandling     string
+	NotFoundHandling string
+}
+
+func NewAssetHandler(dir string, conf *utils.AssetsConfig) *AssetHandler {
+	h := &AssetHandler{
+		Dir:              dir,
+		HTMLHandling:     AutoTrailingSlash,
+		NotFoundHandling: NoNotFoundHandling,
+	}
+
+	if conf != nil && conf.HTMLHandling != "" {
+		h.HTMLHandling = conf.HTMLHandling
+	}
+
+	if conf != nil && conf.NotFoundHandling != "" {
+		h.NotFoundHandling = conf.NotFoundHandling
+	}
+
+	return h
+}
+
+// resolution is the outcome of matching a request path against the assets.
+// Exactly one of File or Redirect is set when the path matched.
+type resolution struct {
+	File     string
+	Redirect string
+}
+
+func (r resolution) matched() bool {
+	return r.File != "" || r.Redirect != ""
+}
+
+// match resolves the raw request path against the assets directory without
+// applying `not_found_handling`.
+func (h *AssetHandler) match(pathname string) resolution {
+	trailingSlash := strings.HasSuffix(pathname, "/")
+	pathname = path.Clean("/" + pathname)
+	if trailingSlash && pathname != "/" {
+		pathname += "/"
+	}
+
+	isHTML := strings.HasSuffix(pathname, ".html")
+
+	if !isHTML || h.HTMLHandling == NoHTMLHandling {
+		if !trailingSlash && h.exists(pathname) {
+			return resolution{File: pathname}
+		}
+	}
+
+	if h.HTMLHandling == NoHTMLHandling {
+		return resolution{}
+	}
+
+	// Strip explicit `.html` and `/index.html` suffixes to the canonical form.
+	if isHTML {
+		if !h.exists(pathname) {
+			return resolution{}
+		}
+
+		base := strings.TrimSuffix(pathname, ".html")
+		if strings.HasSuffix(pathname, "/index.html") {
+			base = strings.TrimSuffix(pathname, "index.html")
+		}
+
+		return resolution{Redirect: h.canonical(base, strings.HasSuffix(pathname, "/index.html"))}
+	}
+
+	if pathname == "/" {
+		if h.exists("/index.html") {
+			return resolution{File: "/index.html"}
+		}
+		return resolution{}
+	}
+
+	base := strings.TrimSuffix(pathname, "/")
+	htmlFile := base + ".html"
+	indexFile := base + "/index.html"
+
+	switch h.HTMLHandling {
+	case ForceTrailingSlash:
+		if !trailingSlash {
+			if h.exists(htmlFile) || h.exists(indexFile) {
+				return resolution{Redirect: base + "/"}
+			}
+			return resolution{}
+		}
+		if h.exists(indexFile) {
+			return resolution{File: indexFile}
+		}
+		if h.exists(htmlFile) {
+			return resolution{File: htmlFile}
+		}
+	case DropTrailingSlash:
+		if trailingSlash {
+			if h.exists(htmlFile) || h.exists(indexFile) {
+				return resolution{Redirect: base}
+			}
+			return resolution{}
+		}
+		if h.exists(htmlFile) {
+			return resolution{File: htmlFile}
+		}
+		if h.exists(indexFile) {
+			return resolution{File: indexFile}
+		}
+	default:
+		if trailingSlash {
+			if h.exists(indexFile) {
+				return resolution{File: indexFile}
+			}
+			if h.exists(htmlFile) {
+				return resolution{Redirect: base}
+			}
+			return resolution{}
+		}
+		if h.exists(htmlFile) {
+			return resolution{File: htmlFile}
+		}
+		if h.exists(indexFile) {
+			return resolution{Redirect: base + "/"}
+		}
+	}
+
+	return resolution{}
+}
+
+// canonical returns the URL an explicit `.html` request is redirected to.
+func (h *AssetHandler) canonical(base string, isIndex bool) string {
+	if base == "/" {
+		return "/"
+	}
+
+	base = strings.TrimSuffix(base, "/")
+
+	switch h.HTMLHandling {
+	case ForceTrailingSlash:
+		return base + "/"
+	case DropTrailingSlash:
+		return base
+	default:
+		if isIndex {
+			return base + "/"
+		}
+		return base
+	}
+}
+
+// notFound applies `not_found_handling` and returns the file to serve, if any.
+func (h *AssetHandler) notFound(pathname string) string {
+	switch h.NotFoundHandling {
+	case SinglePageApplication:
+		if h.exists("/index.html") {
+			return "/index.html"
+		}
+	case NotFoundPage:
+		dir := path.Dir(path.Clean("/" + pathname))
+		for {
+			candidate := path.Join(dir, "404.html")
+			if h.exists(candidate) {
+				return candidate
+			}
+			if dir == "/" {
+				break
+			}
+			dir = path.Dir(dir)
+		}
+	}
+
+	return ""
+}
+
+func contentType(file string) string {
+	if t := mime.TypeByExtension(path.Ext(file)); t != "" {
+		return t
+	}
+
+	return "application/octet-stream"
+}
+
+func (h *AssetHandler) exists(pathname string) bool {
+	info, err := os.Stat(filepath.Join(h.Dir, filepath.FromSlash(pathname)))
+	return err == nil && !info.IsDir()
+}
+
+// ServeHTTP serves matching assets and applies `not_found_handling` for
+// everything else.
+func (h *AssetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.serveAsset(w, r) {
+		return
+	}
+
+	if file := h.notFound(r.URL.Path); file != "" {
+		status := http.StatusNotFound
+		if h.NotFoundHandling == SinglePageApplication {
+			status = http.StatusOK
+		}
+		h.serveFile(w, r, file, status)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// serveAsset writes the matching asset or redirect, and reports whether the
+// request matched at all.
+func (h *AssetHandler) serveAsset(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	match := h.match(r.URL.Path)
+	if !match.matched() {
+		return false
+	}
+
+	if match.Redirect != "" {
+		target := match.Redirect
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusTemporaryRedirect)
+		return true
+	}
+
+	h.serveFile(w, r, match.File, http.StatusOK)
+	return true
+}
+
+func (h *AssetHandler) serveFile(w http.ResponseWriter, r *http.Request, file string, status int) {
+	data, err := os.ReadFile(filepath.Join(h.Dir, filepath.FromSlash(file)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType(file))
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(data)
+	}
+}
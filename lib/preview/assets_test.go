@@ -0,0 +1,88 @@
+package preview
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeAssets(t *testing.T, files ...string) string {
+	dir := t.TempDir()
+
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestAssetHandlerMatch(t *testing.T) {
+	dir := writeAssets(t, "index.html", "about.html", "blog/index.html", "logo.png")
+
+	tests := []struct {
+		name         string
+		htmlHandling string
+		path         string
+		file         string
+		redirect     string
+	}{
+		{"Serve exact file", AutoTrailingSlash, "/logo.png", "/logo.png", ""},
+		{"Serve root index", AutoTrailingSlash, "/", "/index.html", ""},
+		{"Auto serves .html without extension", AutoTrailingSlash, "/about", "/about.html", ""},
+		{"Auto redirects .html", AutoTrailingSlash, "/about.html", "", "/about"},
+		{"Auto redirects directory to trailing slash", AutoTrailingSlash, "/blog", "", "/blog/"},
+		{"Auto serves directory index", AutoTrailingSlash, "/blog/", "/blog/index.html", ""},
+		{"Auto redirects /index.html", AutoTrailingSlash, "/blog/index.html", "", "/blog/"},
+		{"Force redirects to trailing slash", ForceTrailingSlash, "/about", "", "/about/"},
+		{"Force serves .html with trailing slash", ForceTrailingSlash, "/about/", "/about.html", ""},
+		{"Drop redirects trailing slash", DropTrailingSlash, "/blog/", "", "/blog"},
+		{"Drop serves directory index", DropTrailingSlash, "/blog", "/blog/index.html", ""},
+		{"None only serves exact files", NoHTMLHandling, "/about", "", ""},
+		{"None serves .html files", NoHTMLHandling, "/about.html", "/about.html", ""},
+		{"Missing asset", AutoTrailingSlash, "/missing", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &AssetHandler{Dir: dir, HTMLHandling: tt.htmlHandling}
+
+			got := h.match(tt.path)
+			if got.File != tt.file || got.Redirect != tt.redirect {
+				t.Errorf("match(%s) = %+v, want file %q redirect %q", tt.path, got, tt.file, tt.redirect)
+			}
+		})
+	}
+}
+
+func TestAssetHandlerNotFound(t *testing.T) {
+	dir := writeAssets(t, "index.html", "404.html", "docs/404.html")
+
+	tests := []struct {
+		name             string
+		notFoundHandling string
+		path             string
+		expected         string
+	}{
+		{"No handling", NoNotFoundHandling, "/missing", ""},
+		{"Single page application", SinglePageApplication, "/app/settings", "/index.html"},
+		{"Nearest 404 page", NotFoundPage, "/docs/missing", "/docs/404.html"},
+		{"Root 404 page", NotFoundPage, "/blog/missing", "/404.html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &AssetHandler{Dir: dir, NotFoundHandling: tt.notFoundHandling}
+
+			got := h.notFound(tt.path)
+			if got != tt.expected {
+				t.Errorf("notFound(%s) = %q, want %q", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+var ErrNoRuntime = errors.New("no local worker runtime found on PATH (install `workerd` or `miniflare`)")
+
+// Runtime is a local worker runtime process running the bundled module.
+type Runtime struct {
+	Name string
+	Addr string
+	cmd  *exec.Cmd
+	done chan error
+}
+
+type RuntimeOptions struct {
+	// WorkerDir is the absolute path of `.micromachine/worker`.
+	WorkerDir string
+	// MainModule is the entrypoint, relative to WorkerDir.
+	MainModule string
+	// ConfigDir is where generated runtime configuration is written.
+	ConfigDir string
+	// AssetsAddr is the address of the assets-only server backing the
+	// assets binding of the worker.
+	AssetsAddr     string
+	WranglerConfig *utils.WranglerConfig
+}
+
+// StartRuntime launches the first worker runtime found on PATH, preferring
+// workerd over miniflare, and waits until it accepts connections.
+func StartRuntime(opts RuntimeOptions) (*Runtime, error) {
+	addr, err := freeAddr()
+	if err != nil {
+		return nil, err
+	}
+
+	var cmd *exec.Cmd
+	var name string
+
+	if path, err := exec.LookPath("workerd"); err == nil {
+		name = "workerd"
+		configPath, err := writeWorkerdConfig(opts, addr)
+		if err != nil {
+			return nil, err
+		}
+		cmd = exec.Command(path, "serve", configPath, "--verbose")
+	} else if path, err := exec.LookPath("miniflare"); err == nil {
+		name = "miniflare"
+		cmd = exec.Command(path, miniflareArgs(opts, addr)...)
+	} else {
+		return nil, ErrNoRuntime
+	}
+
+	warnUnsupportedBindings(opts.WranglerConfig)
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	utils.LogWithColor(utils.Default, fmt.Sprintf("Starting \033[1m`%s`\033[0m...", name))
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start %s: %w", name, err)
+	}
+
+	runtime := &Runtime{Name: name, Addr: addr, cmd: cmd, done: make(chan error, 1)}
+	go func() {
+		runtime.done <- cmd.Wait()
+	}()
+
+	if err := runtime.waitReady(15 * time.Second); err != nil {
+		_ = runtime.Stop()
+		return nil, err
+	}
+
+	return runtime, nil
+}
+
+func (r *Runtime) waitReady(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-r.done:
+			return fmt.Errorf("%s exited before accepting connections: %v", r.Name, err)
+		default:
+		}
+
+		conn, err := net.DialTimeout("tcp", r.Addr, 200*time.Millisecond)
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("%s did not start listening on %s within %s", r.Name, r.Addr, timeout)
+}
+
+// Stop terminates the runtime process.
+func (r *Runtime) Stop() error {
+	if r.cmd.Process == nil {
+		return nil
+	}
+
+	err := r.cmd.Process.Kill()
+	if err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+
+	<-r.done
+	return nil
+}
+
+func freeAddr() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("could not find a free port: %w", err)
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+
+	return l.Addr().String(), nil
+}
+
+func miniflareArgs(opts RuntimeOptions, addr string) []string {
+	host, port, _ := net.SplitHostPort(addr)
+
+	args := []string{
+		filepath.Join(opts.WorkerDir, opts.MainModule),
+		"--modules",
+		"--host", host,
+		"--port", port,
+	}
+
+	if conf := opts.WranglerConfig; conf != nil {
+		if conf.CompatibilityDate != "" {
+			args = append(args, "--compat-date", conf.CompatibilityDate)
+		}
+		for _, flag := range conf.CompatibilityFlags {
+			args = append(args, "--compat-flag", flag)
+		}
+		for _, key := range slices.Sorted(maps.Keys(conf.Vars)) {
+			args = append(args, "--binding", key+"="+conf.Vars[key])
+		}
+	}
+
+	return args
+}
+
+// writeWorkerdConfig writes a workerd capnp configuration embedding every
+// module of the worker directory and returns its path.
+func writeWorkerdConfig(opts RuntimeOptions, addr string) (string, error) {
+	if err := os.MkdirAll(opts.ConfigDir, 0755); err != nil {
+		return "", fmt.Errorf("could not create preview directory: %w", err)
+	}
+
+	modules, err := workerdModules(opts)
+	if err != nil {
+		return "", err
+	}
+
+	var bindings []string
+	compatibilityDate := time.Now().Format(time.DateOnly)
+	var compatibilityFlags []string
+
+	if conf := opts.WranglerConfig; conf != nil {
+		if conf.CompatibilityDate != "" {
+			compatibilityDate = conf.CompatibilityDate
+		}
+		for _, flag := range conf.CompatibilityFlags {
+			compatibilityFlags = append(compatibilityFlags, strconv.Quote(flag))
+		}
+		for _, key := range slices.Sorted(maps.Keys(conf.Vars)) {
+			bindings = append(bindings, fmt.Sprintf("(name = %s, text = %s)", strconv.Quote(key), strconv.Quote(conf.Vars[key])))
+		}
+		if conf.Assets != nil && conf.Assets.Binding != "" && opts.AssetsAddr != "" {
+			bindings = append(bindings, fmt.Sprintf("(name = %s, service = \"assets\")", strconv.Quote(conf.Assets.Binding)))
+		}
+	}
+
+	services := []string{`(name = "main", worker = .mainWorker)`}
+	if opts.AssetsAddr != "" {
+		services = append(services, fmt.Sprintf(`(name = "assets", external = (address = %s, http = ()))`, strconv.Quote(opts.AssetsAddr)))
+	}
+
+	config := fmt.Sprintf(`using Workerd = import "/workerd/workerd.capnp";
+
+const config :Workerd.Config = (
+  services = [
+    %s
+  ],
+  sockets = [ (name = "http", address = %s, http = (), service = "main") ],
+);
+
+const mainWorker :Workerd.Worker = (
+  modules = [
+    %s
+  ],
+  compatibilityDate = %s,
+  compatibilityFlags = [%s],
+  bindings = [
+    %s
+  ],
+);
+`,
+		strings.Join(services, ",\n    "),
+		strconv.Quote(addr),
+		strings.Join(modules, ",\n    "),
+		strconv.Quote(compatibilityDate),
+		strings.Join(compatibilityFlags, ", "),
+		strings.Join(bindings, ",\n    "),
+	)
+
+	path := filepath.Join(opts.ConfigDir, "workerd.capnp")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		return "", fmt.Errorf("could not write workerd configuration: %w", err)
+	}
+
+	return path, nil
+}
+
+// workerdModules lists the worker directory as workerd module declarations,
+// with the main module first as workerd requires.
+func workerdModules(opts RuntimeOptions) ([]string, error) {
+	main := filepath.ToSlash(opts.MainModule)
+	modules := []string{}
+	var mainModule string
+
+	err := filepath.WalkDir(opts.WorkerDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, _ := filepath.Rel(opts.WorkerDir, path)
+		name := filepath.ToSlash(rel)
+
+		var kind string
+		switch filepath.Ext(path) {
+		case ".js", ".mjs":
+			kind = "esModule"
+		case ".cjs":
+			kind = "commonJsModule"
+		case ".wasm":
+			kind = "wasm"
+		case ".bin":
+			kind = "data"
+		case ".txt", ".html":
+			kind = "text"
+		default:
+			return nil
+		}
+
+		embed, err := filepath.Rel(opts.ConfigDir, path)
+		if err != nil {
+			return err
+		}
+
+		module := fmt.Sprintf("(name = %s, %s = embed %s)", strconv.Quote(name), kind, strconv.Quote(filepath.ToSlash(embed)))
+		if name == main {
+			mainModule = module
+			return nil
+		}
+
+		modules = append(modules, module)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list worker modules: %w", err)
+	}
+
+	if mainModule == "" {
+		return nil, fmt.Errorf("the main module `%s` was not found in `%s`", opts.MainModule, opts.WorkerDir)
+	}
+
+	return append([]string{mainModule}, modules...), nil
+}
+
+func warnUnsupportedBindings(conf *utils.WranglerConfig) {
+	if conf == nil {
+		return
+	}
+
+	var unsupported []string
+	if len(conf.KVNamespaces) > 0 {
+		unsupported = append(unsupported, "kv_namespaces")
+	}
+	if len(conf.R2Buckets) > 0 {
+		unsupported = append(unsupported, "r2_buckets")
+	}
+	if len(conf.D1Databases) > 0 {
+		unsupported = append(unsupported, "d1_databases")
+	}
+	if conf.DurableObjects != nil && len(conf.DurableObjects.Bindings) > 0 {
+		unsupported = append(unsupported, "durable_objects")
+	}
+	if len(conf.Services) > 0 {
+		unsupported = append(unsupported, "services")
+	}
+	if conf.Queues != nil && len(conf.Queues.Producers) > 0 {
+		unsupported = append(unsupported, "queues")
+	}
+
+	if len(unsupported) > 0 {
+		slog.Warn(fmt.Sprintf("The following bindings are not available in preview: %s", strings.Join(unsupported, ", ")))
+	}
+}
@@ -0,0 +1,139 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"micromachine.dev/cmd-utils/lib/utils"
+)
+
+type Options struct {
+	// Addr is the address the preview server listens on.
+	Addr string
+	// AssetDir is the absolute path of `.micromachine/assets`.
+	AssetDir string
+	// WorkerDir is the absolute path of `.micromachine/worker`.
+	WorkerDir string
+	// MainModule is the entrypoint, relative to WorkerDir.
+	MainModule     string
+	WranglerConfig *utils.WranglerConfig
+}
+
+// Server answers requests from the assets directory first and forwards
+// everything else to the worker runtime, like the Workers platform does.
+type Server struct {
+	Assets  *AssetHandler
+	Runtime *Runtime
+	proxy   *httputil.ReverseProxy
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Assets != nil && s.Assets.serveAsset(w, r) {
+		return
+	}
+
+	if s.proxy != nil {
+		// Navigation requests fall back to the SPA shell before reaching the worker.
+		if s.Assets != nil && s.Assets.NotFoundHandling == SinglePageApplication && r.Header.Get("Sec-Fetch-Mode") == "navigate" {
+			s.Assets.ServeHTTP(w, r)
+			return
+		}
+
+		s.proxy.ServeHTTP(w, r)
+		return
+	}
+
+	if s.Assets != nil {
+		s.Assets.ServeHTTP(w, r)
+		return
+	}
+
+	http.Error(w, "No worker runtime is running and no assets were found.", http.StatusBadGateway)
+}
+
+// Serve runs the preview server until ctx is cancelled.
+func Serve(ctx context.Context, opts Options) error {
+	server := &Server{}
+
+	if info, err := os.Stat(opts.AssetDir); err == nil && info.IsDir() {
+		var conf *utils.AssetsConfig
+		if opts.WranglerConfig != nil {
+			conf = opts.WranglerConfig.Assets
+		}
+		server.Assets = NewAssetHandler(opts.AssetDir, conf)
+	}
+
+	var assetsAddr string
+	if server.Assets != nil {
+		// The worker reaches its assets binding through a dedicated listener
+		// so that `env.ASSETS.fetch()` never loops back into the worker.
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return fmt.Errorf("could not start assets server: %w", err)
+		}
+
+		assetsServer := &http.Server{Handler: server.Assets}
+		go func() {
+			_ = assetsServer.Serve(listener)
+		}()
+		defer func() {
+			_ = assetsServer.Close()
+		}()
+
+		assetsAddr = listener.Addr().String()
+	}
+
+	runtime, err := StartRuntime(RuntimeOptions{
+		WorkerDir:      opts.WorkerDir,
+		MainModule:     opts.MainModule,
+		ConfigDir:      filepath.Join(filepath.Dir(opts.WorkerDir), "preview"),
+		AssetsAddr:     assetsAddr,
+		WranglerConfig: opts.WranglerConfig,
+	})
+	switch {
+	case errors.Is(err, ErrNoRuntime):
+		slog.Warn(fmt.Sprintf("%v. Only assets will be served.", err))
+	case err != nil:
+		slog.Error(fmt.Sprintf("%v", err))
+		return err
+	default:
+		defer func() {
+			_ = runtime.Stop()
+		}()
+
+		server.Runtime = runtime
+		server.proxy = httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: runtime.Addr})
+	}
+
+	httpServer := &http.Server{Addr: opts.Addr, Handler: server}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	utils.LogWithColor(utils.Success, fmt.Sprintf("✓ Preview ready on http://%s", opts.Addr))
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error(fmt.Sprintf("%v", err))
+			return err
+		}
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// CacheDirEnv overrides the directory micromachine caches downloads in.
+const CacheDirEnv = "MICROMACHINE_CACHE_DIR"
+
+// CacheDir returns the directory micromachine caches downloads in: the one
+// set by MICROMACHINE_CACHE_DIR, or `micromachine` in the cache directory of
+// the user, e.g. `~/.cache/micromachine`. The directory may not exist yet.
+func CacheDir() (string, error) {
+	if dir := os.Getenv(CacheDirEnv); dir != "" {
+		return filepath.Abs(dir)
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "micromachine"), nil
+}
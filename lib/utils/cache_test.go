@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCacheDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		xdg      string
+		expected string
+	}{
+		{"MICROMACHINE_CACHE_DIR", "/tmp/mm-cache", "/home/me/.cache", "/tmp/mm-cache"},
+		{"User cache directory", "", "/home/me/.cache", "/home/me/.cache/micromachine"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(CacheDirEnv, tt.env)
+			t.Setenv("XDG_CACHE_HOME", tt.xdg)
+
+			if tt.env == "" && (runtime.GOOS == "darwin" || runtime.GOOS == "windows" || runtime.GOOS == "plan9") {
+				t.Skip("the user cache directory does not come from XDG_CACHE_HOME on " + runtime.GOOS)
+			}
+
+			got, err := CacheDir()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != filepath.FromSlash(tt.expected) {
+				t.Errorf("CacheDir() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
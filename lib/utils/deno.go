@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tidwall/jsonc"
+)
+
+// denoConfigFiles are the configuration files of a Deno project, in the
+// order Deno looks for them.
+var denoConfigFiles = []string{"deno.json", "deno.jsonc"}
+
+// DenoConfig is the part of a `deno.json` micromachine uses.
+type DenoConfig struct {
+	// Path is the path of the configuration file.
+	Path  string                     `json:"-"`
+	Tasks map[string]json.RawMessage `json:"tasks,omitempty"`
+	// Imports is the import map, either inline or read from the file
+	// `importMap` points at.
+	Imports   map[string]string `json:"imports,omitempty"`
+	ImportMap string            `json:"importMap,omitempty"`
+}
+
+// FindDenoConfig returns the path of the deno.json or deno.jsonc of rootDir,
+// or an empty string.
+func FindDenoConfig(rootDir string) string {
+	for _, name := range denoConfigFiles {
+		path := filepath.Join(rootDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// ReadDenoConfig parses the Deno configuration of rootDir. It returns nil
+// when the project has none.
+func ReadDenoConfig(rootDir string) (*DenoConfig, error) {
+	path := FindDenoConfig(rootDir)
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &DenoConfig{Path: path}
+	if err := json.Unmarshal(jsonc.ToJSON(data), config); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", filepath.Base(path), err)
+	}
+
+	if config.ImportMap != "" && len(config.Imports) == 0 {
+		importMapPath := filepath.Join(filepath.Dir(path), config.ImportMap)
+		data, err := os.ReadFile(importMapPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the import map of %s: %w", filepath.Base(path), err)
+		}
+
+		var importMap struct {
+			Imports map[string]string `json:"imports"`
+		}
+		if err := json.Unmarshal(jsonc.ToJSON(data), &importMap); err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", config.ImportMap, err)
+		}
+		config.Imports = importMap.Imports
+	}
+
+	return config, nil
+}
+
+// HasTask reports whether the configuration defines the task name.
+func (c *DenoConfig) HasTask(name string) bool {
+	_, ok := c.Tasks[name]
+	return ok
+}
+
+// ResolveImport maps a specifier through the import map: an exact entry
+// wins, then the longest entry ending with `/` that prefixes the specifier.
+// Targets that are relative paths are made absolute against the directory of
+// the configuration. It reports false when no entry matches.
+func (c *DenoConfig) ResolveImport(specifier string) (string, bool) {
+	target, ok := c.Imports[specifier]
+	if !ok {
+		prefix := ""
+		for key := range c.Imports {
+			if strings.HasSuffix(key, "/") && strings.HasPrefix(specifier, key) && len(key) > len(prefix) {
+				prefix = key
+			}
+		}
+		if prefix == "" {
+			return "", false
+		}
+		target = c.Imports[prefix] + strings.TrimPrefix(specifier, prefix)
+	}
+
+	if strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../") {
+		target = filepath.Join(filepath.Dir(c.Path), target)
+	}
+
+	return target, true
+}
+
+// NpmSpecifierPackage turns an `npm:` specifier into the bare specifier of the
+// package in node_modules, dropping the version: `npm:@scope/pkg@^1/sub`
+// becomes `@scope/pkg/sub`.
+func NpmSpecifierPackage(specifier string) (string, error) {
+	rest, ok := strings.CutPrefix(specifier, "npm:")
+	if !ok {
+		return "", fmt.Errorf("`%s` is not an npm specifier", specifier)
+	}
+	rest = strings.TrimPrefix(rest, "/")
+
+	name, subpath := rest, ""
+	scoped := strings.HasPrefix(rest, "@")
+	parts := strings.SplitN(rest, "/", 3)
+
+	switch {
+	case scoped && len(parts) < 2:
+		return "", errors.New("invalid npm specifier `" + specifier + "`")
+	case scoped:
+		name = parts[0] + "/" + parts[1]
+		if len(parts) == 3 {
+			subpath = "/" + parts[2]
+		}
+	default:
+		name, subpath, _ = strings.Cut(rest, "/")
+		if subpath != "" {
+			subpath = "/" + subpath
+		}
+	}
+
+	// Drop the version, keeping the `@` of a scope.
+	if i := strings.LastIndex(name, "@"); i > 0 {
+		name = name[:i]
+	}
+
+	return name + subpath, nil
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDenoConfigResolveImport(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "deno.jsonc"), []byte(`{
+		// Comments are allowed
+		"tasks": { "build": "deno run -A build.ts" },
+		"imports": {
+			"hono": "npm:hono@^4.6.0",
+			"hono/": "npm:/hono@^4.6.0/",
+			"@/": "./src/",
+			"@std/path": "jsr:@std/path@^1.0.0"
+		}
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := ReadDenoConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.HasTask("build") {
+		t.Error("Expected the `build` task to be found")
+	}
+
+	tests := []struct {
+		specifier string
+		expected  string
+		found     bool
+	}{
+		{"hono", "npm:hono@^4.6.0", true},
+		{"hono/jsx", "npm:/hono@^4.6.0/jsx", true},
+		{"@/routes/index.ts", filepath.Join(dir, "src/routes/index.ts"), true},
+		{"@std/path", "jsr:@std/path@^1.0.0", true},
+		{"zod", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.specifier, func(t *testing.T) {
+			got, found := config.ResolveImport(tt.specifier)
+			if got != tt.expected || found != tt.found {
+				t.Errorf("Expected %q (%v), got %q (%v)", tt.expected, tt.found, got, found)
+			}
+		})
+	}
+}
+
+func TestReadDenoConfigImportMap(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "deno.json"), []byte(`{"importMap": "./import_map.json"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "import_map.json"), []byte(`{"imports": {"preact": "npm:preact@10"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := ReadDenoConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := config.ResolveImport("preact"); got != "npm:preact@10" {
+		t.Errorf("Expected the import map to be read, got %q", got)
+	}
+}
+
+func TestNpmSpecifierPackage(t *testing.T) {
+	tests := []struct {
+		specifier string
+		expected  string
+	}{
+		{"npm:hono", "hono"},
+		{"npm:hono@^4.6.0", "hono"},
+		{"npm:hono@4/jsx", "hono/jsx"},
+		{"npm:/hono@4/jsx/dom", "hono/jsx/dom"},
+		{"npm:@cloudflare/workers-types@4", "@cloudflare/workers-types"},
+		{"npm:@scope/pkg/sub/path", "@scope/pkg/sub/path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.specifier, func(t *testing.T) {
+			got, err := NpmSpecifierPackage(tt.specifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
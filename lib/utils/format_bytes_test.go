@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size     int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{3 * 1024 * 1024, "3.0 MiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := FormatBytes(tt.size); got != tt.expected {
+				t.Errorf("Expected %d bytes to be %s, got %s", tt.size, tt.expected, got)
+			}
+		})
+	}
+}
@@ -4,10 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -28,6 +28,28 @@ type PackageJSON struct {
 	Engines              map[string]string `json:"engines,omitempty"`
 	PackageManager       string            `json:"packageManager,omitempty"` // e.g., "pnpm@8.6.0"
 	Workspaces           json.RawMessage   `json:"workspaces,omitempty"`     // can be []string or object
+	Micromachine         *ProjectConfig    `json:"micromachine,omitempty"`
+}
+
+// ProjectConfig holds the micromachine settings of a project, read from the
+// `micromachine` key of its package.json.
+type ProjectConfig struct {
+	SizeBudget *SizeBudgetConfig `json:"sizeBudget,omitempty"`
+}
+
+// ReadPackageJSON parses the package.json of rootDir.
+func ReadPackageJSON(rootDir string) (*PackageJSON, error) {
+	data, err := os.ReadFile(filepath.Join(rootDir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var packageJSON PackageJSON
+	if err := json.Unmarshal(data, &packageJSON); err != nil {
+		return nil, fmt.Errorf("could not parse package.json: %w", err)
+	}
+
+	return &packageJSON, nil
 }
 
 func (p *PackageJSON) HasDependency(name string) bool {
@@ -104,10 +126,13 @@ import { resolveConfig } from 'vite';
 const config = await resolveConfig({}, 'build');
 console.log(JSON.stringify(config.plugins.map(p => p.name)));
 `
-	cmd := exec.Command("node", "--input-type=module", "-e", script)
-	cmd.Dir = projectDir
+	cmd := NodeCommand(projectDir, "--input-type=module", "-e", script)
 	out, err := cmd.Output()
 	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("node failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
 		return nil, err
 	}
 
@@ -118,51 +143,59 @@ console.log(JSON.stringify(config.plugins.map(p => p.name)));
 	return names, nil
 }
 
-func IncludeCloudflareVitePlugin(rootDir, packageManager string) *string {
-	checks, err := HasCloudflareVitePlugin(rootDir)
-	if err != nil {
-		return nil
-	}
+// ViteConfigFile is the vite configuration AddVitePlugin writes next to the
+// one of the project.
+const ViteConfigFile = "micromachine-vite.config.ts"
 
-	if !checks.HasDependency {
-		slog.Warn("We couldn't find the @cloudflare/vite-plugin dependency in your package.json. Adding it now...")
-		cmd := exec.Command(packageManager, "install", "-D", "@cloudflare/vite-plugin")
-		cmd.Dir = rootDir
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		err := cmd.Run()
+// IncludeCloudflareVitePlugin writes a configuration adding
+// `@cloudflare/vite-plugin`, which must be installed, when the vite config of
+// rootDir does not use it. It returns the name of that configuration, or nil
+// when the project config can be used as-is or rootDir is not a vite app.
+func IncludeCloudflareVitePlugin(rootDir string, viteEnvironment string) (*string, error) {
+	if _, ok := IsViteApp(rootDir); !ok {
+		return nil, nil
+	}
 
-		if err != nil {
-			slog.Error("Failed to add `@cloudflare/vite-plugin` vite plugin")
-			os.Exit(1)
-		}
+	checks, err := HasCloudflareVitePlugin(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve the vite config to add `@cloudflare/vite-plugin`: %w", err)
 	}
 
 	if !checks.IsPlugin {
-		slog.Warn("We couldn't find the @cloudflare/vite-plugin plugin in your vite.config.js. Adding it now...")
-		return AddVitePlugin(rootDir, *checks.Path, packageManager)
+		LogWithColor(Muted, fmt.Sprintf("%s does not use `@cloudflare/vite-plugin`, building with `%s`", filepath.Base(*checks.Path), ViteConfigFile))
+		return AddVitePlugin(rootDir, *checks.Path, viteEnvironment)
 	}
-	return nil
+	return nil, nil
 }
 
-func AddVitePlugin(rootDir, configPath, packageManager string) *string {
-	script := fmt.Sprintf(`
-		import { cloudflare } from '@cloudflare/vite-plugin';
+// AddVitePlugin writes ViteConfigFile, which merges the vite config at
+// configPath with `@cloudflare/vite-plugin`. viteEnvironment is the Vite
+// environment the worker runs in, e.g. `ssr`; when empty, the plugin creates
+// one for the worker.
+func AddVitePlugin(rootDir, configPath, viteEnvironment string) (*string, error) {
+	options := ""
+	if viteEnvironment != "" {
+		options = fmt.Sprintf("{ viteEnvironment: { name: %s } }", strconv.Quote(viteEnvironment))
+	}
+
+	script := fmt.Sprintf(`// Generated by micromachine. Do not edit by hand.
+import { cloudflare } from '@cloudflare/vite-plugin';
+import { defineConfig, mergeConfig } from 'vite';
 
-		import userConfig from '%s';
-		import { defineConfig, mergeConfig } from 'vite';
+import userConfig from './%s';
 
-		export default mergeConfig(userConfig, defineConfig({
-		plugins: [cloudflare({viteEnvironment: {name: 'ssr'}})]
-	}));`, filepath.Base(configPath))
+export default defineConfig(async (env) => mergeConfig(
+	typeof userConfig === 'function' ? await userConfig(env) : userConfig,
+	{ plugins: [cloudflare(%s)] },
+));
+`, filepath.Base(configPath), options)
 
-	path := filepath.Join(rootDir, "micromachine-vite.config.ts")
+	path := filepath.Join(rootDir, ViteConfigFile)
 	err := os.WriteFile(path, []byte(script), 0644)
 	if err != nil {
-		slog.Error("Could not not add `@cloudflare/vite-plugin` to your vite.config.ts")
-		os.Exit(1)
+		return nil, fmt.Errorf("could not write `%s`: %w", ViteConfigFile, err)
 	}
 
 	file := filepath.Base(path)
-	return &file
+	return &file, nil
 }
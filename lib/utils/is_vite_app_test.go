@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddVitePlugin(t *testing.T) {
+	tests := []struct {
+		name            string
+		viteEnvironment string
+		expected        string
+		unexpected      string
+	}{
+		{"SSR environment", "ssr", "cloudflare({ viteEnvironment: { name: \"ssr\" } })", ""},
+		{"Worker environment", "", "cloudflare()", "viteEnvironment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			file, err := AddVitePlugin(dir, filepath.Join(dir, "vite.config.ts"), tt.viteEnvironment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *file != ViteConfigFile {
+				t.Errorf("Expected %q, got %q", ViteConfigFile, *file)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, ViteConfigFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			config := string(data)
+
+			if !strings.Contains(config, "import userConfig from './vite.config.ts';") {
+				t.Errorf("Expected the project config to be imported, got:\n%s", config)
+			}
+			if !strings.Contains(config, tt.expected) {
+				t.Errorf("Expected config to contain %q, got:\n%s", tt.expected, config)
+			}
+			if tt.unexpected != "" && strings.Contains(config, tt.unexpected) {
+				t.Errorf("Expected config not to contain %q, got:\n%s", tt.unexpected, config)
+			}
+		})
+	}
+}
@@ -4,54 +4,288 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 )
 
-func DetectPackageManager(root *string) (*string, error) {
+// packageManagerLockfiles maps the lockfiles and configuration files
+// DetectPackageManager looks for, in order, to their package manager.
+var packageManagerLockfiles = []struct {
+	lockfile       string
+	packageManager string
+}{
+	{"bun.lock", "bun"},
+	{"bun.lockb", "bun"},
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "yarn"},
+	{"deno.lock", "deno"},
+	{"pnpm-workspace.yaml", "pnpm"},
+	{"deno.json", "deno"},
+	{"deno.jsonc", "deno"},
+}
+
+// PackageManager is the package manager of a project, e.g. pnpm, along with
+// the version and corepack hash declared by `packageManager` in package.json,
+// e.g. `pnpm@8.6.0+sha512.abc`. The version is empty when the package manager
+// is chosen from a lockfile, except for yarn classic lockfiles, which give
+// major version `1`.
+type PackageManager struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Hash    string `json:"hash,omitempty"`
+}
+
+// ParsePackageManager parses the `packageManager` field of package.json,
+// `<name>@<version>[+<hash>]`.
+func ParsePackageManager(spec string) (PackageManager, error) {
+	name, version, found := strings.Cut(spec, "@")
+	if !found || name == "" || version == "" {
+		return PackageManager{}, fmt.Errorf("`packageManager` must be `<name>@<version>`, got `%s`", spec)
+	}
+
+	version, hash, _ := strings.Cut(version, "+")
+	return PackageManager{Name: name, Version: version, Hash: hash}, nil
+}
+
+// String returns `<name>@<version>`, or the name alone when the version is
+// unknown.
+func (pm PackageManager) String() string {
+	if pm.Version == "" {
+		return pm.Name
+	}
+
+	return pm.Name + "@" + pm.Version
+}
+
+// Major returns the major version, 0 when it is unknown.
+func (pm PackageManager) Major() int {
+	major, _, _ := strings.Cut(pm.Version, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
+
+// IsYarnClassic reports whether pm is yarn 1, which has no `dlx` and other
+// flags than yarn berry. A yarn of unknown version is taken to be berry.
+func (pm PackageManager) IsYarnClassic() bool {
+	return pm.Name == "yarn" && pm.Major() == 1
+}
+
+// Install returns the command installing the dependencies of the project.
+// When frozen is set, the install fails instead of updating the lockfile.
+func (pm PackageManager) Install(frozen bool) (string, []string) {
+	switch pm.Name {
+	case "npm":
+		if frozen {
+			return "npm", []string{"ci"}
+		}
+		return "npm", []string{"install"}
+	case "yarn":
+		args := []string{"install"}
+		switch {
+		case frozen && pm.IsYarnClassic():
+			args = append(args, "--frozen-lockfile")
+		case frozen:
+			args = append(args, "--immutable")
+		}
+		return "yarn", args
+	case "deno":
+		args := []string{"install"}
+		if frozen {
+			args = append(args, "--frozen")
+		}
+		return "deno", args
+	default:
+		args := []string{"install"}
+		if frozen {
+			args = append(args, "--frozen-lockfile")
+		}
+		return pm.Name, args
+	}
+}
+
+// AddDev returns the command adding packages, e.g. `unenv@2.0.0`, to the
+// dev dependencies of the project.
+func (pm PackageManager) AddDev(packages ...string) (string, []string) {
+	switch pm.Name {
+	case "npm":
+		return "npm", append([]string{"install", "-D"}, packages...)
+	case "bun":
+		return "bun", append([]string{"add", "-d"}, packages...)
+	case "deno":
+		args := []string{"add", "--dev"}
+		for _, pkg := range packages {
+			args = append(args, "npm:"+pkg)
+		}
+		return "deno", args
+	default:
+		return pm.Name, append([]string{"add", "-D"}, packages...)
+	}
+}
+
+// Run returns the command running a package.json script, or a Deno task,
+// with args.
+func (pm PackageManager) Run(script string, args ...string) (string, []string) {
+	if pm.Name == "deno" {
+		return "deno", append([]string{"task", script}, pm.scriptArgs(args)...)
+	}
+
+	return pm.Name, append([]string{"run", script}, pm.scriptArgs(args)...)
+}
+
+// RunWorkspace returns the command running the script of the workspace
+// package pkg from the root of the workspace, with the filter of the package
+// manager.
+func (pm PackageManager) RunWorkspace(pkg, script string, args ...string) (string, []string) {
+	var argv []string
+	switch pm.Name {
+	case "pnpm":
+		argv = []string{"--filter", pkg, "run", script}
+	case "yarn":
+		argv = []string{"workspace", pkg, "run", script}
+	case "bun":
+		argv = []string{"run", "--filter", pkg, script}
+	case "deno":
+		return pm.Run(script, args...)
+	default:
+		argv = []string{"run", script, "--workspace", pkg}
+	}
+
+	return pm.Name, append(argv, pm.scriptArgs(args)...)
+}
+
+// Exec returns the command downloading, if needed, and running the
+// executable of the package pkg with args.
+func (pm PackageManager) Exec(pkg string, args ...string) (string, []string) {
+	switch {
+	case pm.Name == "pnpm":
+		return "pnpm", append([]string{"dlx", pkg}, args...)
+	case pm.Name == "yarn" && !pm.IsYarnClassic():
+		return "yarn", append([]string{"dlx", pkg}, args...)
+	case pm.Name == "bun":
+		return "bunx", append([]string{pkg}, args...)
+	case pm.Name == "deno":
+		return "deno", append([]string{"run", "-A", "npm:" + pkg}, args...)
+	default:
+		// npm, and yarn classic which has no `dlx`.
+		return "npx", append([]string{pkg}, args...)
+	}
+}
+
+// scriptArgs returns the arguments of a script. npm only passes the ones
+// following `--` to the script.
+func (pm PackageManager) scriptArgs(args []string) []string {
+	if len(args) > 0 && pm.Name == "npm" {
+		return append([]string{"--"}, args...)
+	}
+
+	return args
+}
+
+func DetectPackageManager(root *string) (*PackageManager, error) {
 	rootDir := ""
 	if root != nil {
 		rootDir = *root
 	}
 
-	data, err := os.ReadFile(rootDir + "/package.json")
-
+	pm, evidence, err := ResolvePackageManager(rootDir)
 	if err != nil {
 		return nil, err
 	}
 
-	packageJson := map[string]any{}
+	if evidence != "" {
+		LogWithColor(Default, fmt.Sprintf("Detected \033[1m`%s`\033[0m package manager", pm))
+	}
+
+	return &pm, nil
+}
+
+// ResolvePackageManager returns the package manager of rootDir along with what
+// it was chosen on: the `packageManager` field of package.json or a lockfile,
+// of rootDir or else of the root of its workspace. The evidence is empty when
+// it falls back to npm.
+func ResolvePackageManager(rootDir string) (PackageManager, string, error) {
+	data, err := os.ReadFile(filepath.Join(rootDir, "package.json"))
 
-	err = json.Unmarshal(data, &packageJson)
 	if err != nil {
-		return nil, err
+		// Deno projects do not need a package.json.
+		if path := FindDenoConfig(rootDir); path != "" && os.IsNotExist(err) {
+			return PackageManager{Name: "deno"}, fmt.Sprintf("found `%s`", filepath.Base(path)), nil
+		}
+		return PackageManager{}, "", err
 	}
 
-	if pm, ok := packageJson["packageManager"].(string); ok {
-		before, _, found := strings.Cut(pm, "@")
-		if found {
-			LogWithColor(Default, fmt.Sprintf("Detected \033[1m`%s`\033[0m package manager", before))
-			return &before, nil
+	pm, evidence, err := declaredPackageManager(rootDir, data)
+	if err != nil || evidence != "" {
+		return pm, evidence, err
+	}
+
+	workspace, err := FindWorkspace(rootDir)
+	if err == nil && workspace != nil {
+		data, _ := os.ReadFile(filepath.Join(workspace.Root, "package.json"))
+		pm, evidence, err := declaredPackageManager(workspace.Root, data)
+		if err == nil && evidence != "" {
+			return pm, fmt.Sprintf("%s in the workspace root `%s`", evidence, workspace.RelRoot(rootDir)), nil
 		}
 	}
 
-	if _, err = os.Stat(rootDir + "/bun.lock"); err == nil {
-		pm := "bun"
-		LogWithColor(Default, fmt.Sprintf("Detected \033[1m`%s`\033[0m package manager", pm))
-		return &pm, nil
+	return PackageManager{Name: "npm"}, "", nil
+}
+
+// declaredPackageManager returns the package manager set by the
+// `packageManager` field of the package.json data, which may be nil, or by a
+// lockfile of dir.
+func declaredPackageManager(dir string, data []byte) (PackageManager, string, error) {
+	if data != nil {
+		packageJson := map[string]any{}
+
+		err := json.Unmarshal(data, &packageJson)
+		if err != nil {
+			return PackageManager{}, "", err
+		}
+
+		if spec, ok := packageJson["packageManager"].(string); ok {
+			if pm, err := ParsePackageManager(spec); err == nil {
+				return pm, fmt.Sprintf("`packageManager` is `%s` in package.json", spec), nil
+			}
+		}
 	}
 
-	if _, err = os.Stat(rootDir + "/pnpm-lock.yaml"); err == nil {
-		pm := "pnpm"
-		LogWithColor(Default, fmt.Sprintf("Detected \033[1m`%s`\033[0m package manager", pm))
-		return &pm, nil
+	for _, candidate := range packageManagerLockfiles {
+		if _, err := os.Stat(filepath.Join(dir, candidate.lockfile)); err == nil {
+			pm := PackageManager{Name: candidate.packageManager}
+			if candidate.lockfile == "yarn.lock" && isYarnClassicLockfile(dir) {
+				pm.Version = "1"
+				return pm, "found `yarn.lock` of yarn classic", nil
+			}
+			return pm, fmt.Sprintf("found `%s`", candidate.lockfile), nil
+		}
 	}
 
-	if _, err = os.Stat(rootDir + "/yarn.lock"); err == nil {
-		pm := "yarn"
-		LogWithColor(Default, fmt.Sprintf("Detected \033[1m`%s`\033[0m package manager", pm))
-		return &pm, nil
+	return PackageManager{}, "", nil
+}
+
+// isYarnClassicLockfile reports whether the yarn.lock of dir was written by
+// yarn 1, whose lockfiles start with `# yarn lockfile v1`, and the project is
+// not set up for yarn berry with a `.yarnrc.yml`.
+func isYarnClassicLockfile(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ".yarnrc.yml")); err == nil {
+		return false
+	}
+
+	file, err := os.Open(filepath.Join(dir, "yarn.lock"))
+	if err != nil {
+		return false
 	}
+	defer func() {
+		_ = file.Close()
+	}()
 
-	npm := "npm"
-	return &npm, nil
+	header := make([]byte, 256)
+	n, _ := file.Read(header)
+	return strings.Contains(string(header[:n]), "yarn lockfile v1")
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -77,8 +78,8 @@ func TestDetectPackageManager(t *testing.T) {
 				return
 			}
 
-			if *got != tt.expected {
-				t.Errorf("DetectPackageManager(%s) = %s, want %s", dir, *got, tt.expected)
+			if got.Name != tt.expected {
+				t.Errorf("DetectPackageManager(%s) = %s, want %s", dir, got.Name, tt.expected)
 			}
 		})
 	}
@@ -91,3 +92,118 @@ func TestMissingPackageManager(t *testing.T) {
 		t.Error("Expected error when no package manager found")
 	}
 }
+
+func TestResolvePackageManagerEvidence(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+		evidence string
+	}{
+		{"packageManager field", map[string]string{"package.json": `{"packageManager": "pnpm@8.6.0"}`, "yarn.lock": ""}, "pnpm@8.6.0", "`packageManager` is `pnpm@8.6.0` in package.json"},
+		{"Lockfile", map[string]string{"package.json": `{}`, "yarn.lock": ""}, "yarn", "found `yarn.lock`"},
+		{"Yarn classic lockfile", map[string]string{"package.json": `{}`, "yarn.lock": "# THIS IS AN AUTOGENERATED FILE.\n# yarn lockfile v1\n"}, "yarn@1", "found `yarn.lock` of yarn classic"},
+		{"Yarn berry lockfile", map[string]string{"package.json": `{}`, "yarn.lock": "__metadata:\n  version: 8\n"}, "yarn", "found `yarn.lock`"},
+		{"Deno lockfile", map[string]string{"package.json": `{}`, "deno.lock": "{}"}, "deno", "found `deno.lock`"},
+		{"Deno without package.json", map[string]string{"deno.jsonc": "{}"}, "deno", "found `deno.jsonc`"},
+		{"Fallback", map[string]string{"package.json": `{}`}, "npm", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pm, evidence, err := ResolvePackageManager(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pm.String() != tt.expected || evidence != tt.evidence {
+				t.Errorf("Expected %s (%q), got %s (%q)", tt.expected, tt.evidence, pm, evidence)
+			}
+		})
+	}
+}
+
+func TestParsePackageManager(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected PackageManager
+		wantErr  bool
+	}{
+		{"Name and version", "pnpm@8.6.0", PackageManager{Name: "pnpm", Version: "8.6.0"}, false},
+		{"Corepack hash", "yarn@4.1.0+sha224.953c8233f7a92884eee2de69a1b92d1f2ec1655e66d08071ba9a02fa", PackageManager{Name: "yarn", Version: "4.1.0", Hash: "sha224.953c8233f7a92884eee2de69a1b92d1f2ec1655e66d08071ba9a02fa"}, false},
+		{"Missing version", "npm", PackageManager{}, true},
+		{"Empty version", "bun@", PackageManager{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePackageManager(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePackageManager(%q) error = %v, want error: %v", tt.spec, err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ParsePackageManager(%q) = %+v, want %+v", tt.spec, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPackageManagerCommands(t *testing.T) {
+	npm := PackageManager{Name: "npm", Version: "10.2.0"}
+	pnpm := PackageManager{Name: "pnpm", Version: "9.1.0"}
+	yarnClassic := PackageManager{Name: "yarn", Version: "1.22.19"}
+	yarnBerry := PackageManager{Name: "yarn", Version: "4.1.0"}
+	bun := PackageManager{Name: "bun"}
+	deno := PackageManager{Name: "deno"}
+
+	command := func(name string, args []string) string {
+		return strings.Join(append([]string{name}, args...), " ")
+	}
+
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{"npm frozen install", command(npm.Install(true)), "npm ci"},
+		{"npm install", command(npm.Install(false)), "npm install"},
+		{"pnpm frozen install", command(pnpm.Install(true)), "pnpm install --frozen-lockfile"},
+		{"yarn classic frozen install", command(yarnClassic.Install(true)), "yarn install --frozen-lockfile"},
+		{"yarn berry frozen install", command(yarnBerry.Install(true)), "yarn install --immutable"},
+		{"bun frozen install", command(bun.Install(true)), "bun install --frozen-lockfile"},
+		{"deno frozen install", command(deno.Install(true)), "deno install --frozen"},
+		{"npm add dev", command(npm.AddDev("unenv", "hono")), "npm install -D unenv hono"},
+		{"yarn add dev", command(yarnClassic.AddDev("unenv")), "yarn add -D unenv"},
+		{"bun add dev", command(bun.AddDev("unenv")), "bun add -d unenv"},
+		{"deno add dev", command(deno.AddDev("unenv@2")), "deno add --dev npm:unenv@2"},
+		{"npm run with arguments", command(npm.Run("build", "--config", "x")), "npm run build -- --config x"},
+		{"npm run", command(npm.Run("build")), "npm run build"},
+		{"pnpm run with arguments", command(pnpm.Run("build", "--config", "x")), "pnpm run build --config x"},
+		{"deno task", command(deno.Run("build", "--prod")), "deno task build --prod"},
+		{"npm workspace run", command(npm.RunWorkspace("web", "build", "--x")), "npm run build --workspace web -- --x"},
+		{"pnpm workspace run", command(pnpm.RunWorkspace("web", "build")), "pnpm --filter web run build"},
+		{"npm exec", command(npm.Exec("wrangler", "types")), "npx wrangler types"},
+		{"npm exec opennextjs-cloudflare", command(npm.Exec("opennextjs-cloudflare", "build")), "npx opennextjs-cloudflare build"},
+		{"deno exec opennextjs-cloudflare", command(deno.Exec("opennextjs-cloudflare", "build")), "deno run -A npm:opennextjs-cloudflare build"},
+		{"pnpm exec", command(pnpm.Exec("wrangler", "types")), "pnpm dlx wrangler types"},
+		{"yarn classic exec", command(yarnClassic.Exec("wrangler", "types")), "npx wrangler types"},
+		{"yarn berry exec", command(yarnBerry.Exec("wrangler", "types")), "yarn dlx wrangler types"},
+		{"bun exec", command(bun.Exec("wrangler")), "bunx wrangler"},
+		{"deno exec", command(deno.Exec("wrangler", "types")), "deno run -A npm:wrangler types"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("got `%s`, want `%s`", tt.got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Pnp is the Plug'n'Play install of a Yarn Berry project, which has no
+// node_modules: packages are resolved through the data of `.pnp.cjs`, or of
+// `.pnp.data.json` when yarn does not inline it. esbuild reads that data
+// itself; node needs the hooks of the install to be loaded.
+type Pnp struct {
+	// Root is the absolute path of the directory of the manifest, the root of
+	// the yarn project.
+	Root string
+	// Manifest is the absolute path of `.pnp.cjs`.
+	Manifest string
+	// Loader is the absolute path of `.pnp.loader.mjs`, empty when yarn did
+	// not write one.
+	Loader string
+}
+
+// FindPnp walks up from rootDir looking for the `.pnp.cjs` of a Yarn
+// Plug'n'Play install. It returns nil when the project is installed in
+// node_modules.
+func FindPnp(rootDir string) *Pnp {
+	absDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil
+	}
+
+	for dir := absDir; ; dir = filepath.Dir(dir) {
+		manifest := filepath.Join(dir, ".pnp.cjs")
+		if _, err := os.Stat(manifest); err == nil {
+			pnp := &Pnp{Root: dir, Manifest: manifest}
+			if loader := filepath.Join(dir, ".pnp.loader.mjs"); fileExists(loader) {
+				pnp.Loader = loader
+			}
+			return pnp
+		}
+
+		// Do not leave the repository of the project.
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || filepath.Dir(dir) == dir {
+			return nil
+		}
+	}
+}
+
+// RelManifest returns the manifest relative to rootDir, e.g. `../../.pnp.cjs`.
+func (p *Pnp) RelManifest(rootDir string) string {
+	absDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return p.Manifest
+	}
+
+	rel, err := filepath.Rel(absDir, p.Manifest)
+	if err != nil {
+		return p.Manifest
+	}
+
+	return rel
+}
+
+// Evidence tells which files the install was recognised by.
+func (p *Pnp) Evidence() string {
+	if fileExists(filepath.Join(p.Root, ".pnp.data.json")) {
+		return "found `.pnp.cjs` and `.pnp.data.json`"
+	}
+
+	return "found `.pnp.cjs`"
+}
+
+// NodeArgs returns the flags loading the resolution hooks of the install in
+// node: `.pnp.cjs` for require and, when present, `.pnp.loader.mjs` for
+// imports.
+func (p *Pnp) NodeArgs() []string {
+	args := []string{"--require", p.Manifest}
+	if p.Loader != "" {
+		path := filepath.ToSlash(p.Loader)
+		if !strings.HasPrefix(path, "/") {
+			// Windows drive letters, e.g. `file:///C:/project/.pnp.loader.mjs`.
+			path = "/" + path
+		}
+		loader := &url.URL{Scheme: "file", Path: path}
+		args = append(args, "--experimental-loader", loader.String())
+	}
+
+	return args
+}
+
+// NodeCommand returns a command running node in dir with args, loading the
+// Plug'n'Play hooks of the project when it has any.
+func NodeCommand(dir string, args ...string) *exec.Cmd {
+	if pnp := FindPnp(dir); pnp != nil {
+		args = append(pnp.NodeArgs(), args...)
+	}
+
+	cmd := exec.Command("node", args...)
+	cmd.Dir = dir
+	return cmd
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
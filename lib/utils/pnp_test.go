@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFindPnp(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		app      string
+		manifest string
+		loader   bool
+		evidence string
+	}{
+		{
+			"Manifest in the root",
+			map[string]string{".pnp.cjs": "", "package.json": "{}"},
+			".",
+			".pnp.cjs",
+			false,
+			"found `.pnp.cjs`",
+		},
+		{
+			"Manifest of the workspace root",
+			map[string]string{
+				".pnp.cjs":              "",
+				".pnp.data.json":        "{}",
+				".pnp.loader.mjs":       "",
+				"apps/web/package.json": "{}",
+			},
+			"apps/web",
+			"../../.pnp.cjs",
+			true,
+			"found `.pnp.cjs` and `.pnp.data.json`",
+		},
+		{
+			"No manifest",
+			map[string]string{"package.json": "{}", "yarn.lock": ""},
+			".",
+			"",
+			false,
+			"",
+		},
+		{
+			"Manifest outside of the repository",
+			map[string]string{".pnp.cjs": "", "repo/.git/HEAD": "", "repo/package.json": "{}"},
+			"repo",
+			"",
+			false,
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeWorkspace(t, tt.files)
+			app := filepath.Join(dir, tt.app)
+
+			pnp := FindPnp(app)
+			if tt.manifest == "" {
+				if pnp != nil {
+					t.Errorf("FindPnp() = %v, want nil", pnp)
+				}
+				return
+			}
+
+			if pnp == nil {
+				t.Fatalf("FindPnp() = nil, want %s", tt.manifest)
+			}
+			if got := pnp.RelManifest(app); got != filepath.FromSlash(tt.manifest) {
+				t.Errorf("RelManifest() = %s, want %s", got, tt.manifest)
+			}
+			if got := pnp.Loader != ""; got != tt.loader {
+				t.Errorf("Loader = %q, want a loader: %v", pnp.Loader, tt.loader)
+			}
+			if got := pnp.Evidence(); got != tt.evidence {
+				t.Errorf("Evidence() = %s, want %s", got, tt.evidence)
+			}
+		})
+	}
+}
+
+func TestPnpNodeArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		pnp  Pnp
+		want []string
+	}{
+		{
+			"Require hook only",
+			Pnp{Root: "/app", Manifest: "/app/.pnp.cjs"},
+			[]string{"--require", "/app/.pnp.cjs"},
+		},
+		{
+			"Require and import hooks",
+			Pnp{Root: "/app", Manifest: "/app/.pnp.cjs", Loader: "/app/.pnp.loader.mjs"},
+			[]string{"--require", "/app/.pnp.cjs", "--experimental-loader", "file:///app/.pnp.loader.mjs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pnp.NodeArgs(); !slices.Equal(got, tt.want) {
+				t.Errorf("NodeArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
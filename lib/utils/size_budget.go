@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Compressed script size limits of the Workers plans.
+const (
+	FreePlanScriptSize = 3 << 20
+	PaidPlanScriptSize = 10 << 20
+)
+
+// SizeBudgetConfig is the `micromachine.sizeBudget` setting of package.json.
+// Sizes are strings such as `8 MiB` or `750KB`.
+type SizeBudgetConfig struct {
+	// Plan is `free` or `paid` and sets the default Max.
+	Plan string `json:"plan,omitempty"`
+	// Max overrides the limit of the plan.
+	Max string `json:"max,omitempty"`
+	// Warn is a threshold above which the build only warns.
+	Warn string `json:"warn,omitempty"`
+}
+
+// SizeBudget is the resolved budget of the compressed worker size, in bytes.
+// A zero Warn disables the warning threshold.
+type SizeBudget struct {
+	Max  int64
+	Warn int64
+}
+
+// ReadSizeBudgetConfig returns the size budget configured in the package.json
+// of rootDir, if any.
+func ReadSizeBudgetConfig(rootDir string) (SizeBudgetConfig, error) {
+	packageJSON, err := ReadPackageJSON(rootDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return SizeBudgetConfig{}, nil
+		}
+		return SizeBudgetConfig{}, err
+	}
+
+	if packageJSON.Micromachine == nil || packageJSON.Micromachine.SizeBudget == nil {
+		return SizeBudgetConfig{}, nil
+	}
+
+	return *packageJSON.Micromachine.SizeBudget, nil
+}
+
+// Merge returns c with the non-empty settings of override applied.
+func (c SizeBudgetConfig) Merge(override SizeBudgetConfig) SizeBudgetConfig {
+	if override.Plan != "" {
+		c.Plan = override.Plan
+	}
+	if override.Max != "" {
+		c.Max = override.Max
+	}
+	if override.Warn != "" {
+		c.Warn = override.Warn
+	}
+
+	return c
+}
+
+// Resolve turns the configuration into a budget, defaulting to the limit of
+// the paid plan.
+func (c SizeBudgetConfig) Resolve() (*SizeBudget, error) {
+	budget := &SizeBudget{}
+
+	switch strings.ToLower(c.Plan) {
+	case "", "paid":
+		budget.Max = PaidPlanScriptSize
+	case "free":
+		budget.Max = FreePlanScriptSize
+	default:
+		return nil, fmt.Errorf("unknown plan `%s`, expected `free` or `paid`", c.Plan)
+	}
+
+	if c.Max != "" {
+		size, err := ParseBytes(c.Max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max size: %w", err)
+		}
+		budget.Max = size
+	}
+
+	if c.Warn != "" {
+		size, err := ParseBytes(c.Warn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid warn size: %w", err)
+		}
+		budget.Warn = size
+	}
+
+	return budget, nil
+}
+
+// ParseBytes parses sizes such as `3MiB`, `750 KB` or `1024`. Decimal (KB, MB)
+// and binary (KiB, MiB) units are both accepted.
+func ParseBytes(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	if i < 0 {
+		i = len(value)
+	}
+
+	number, err := strconv.ParseFloat(value[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size `%s`", value)
+	}
+
+	multipliers := map[string]float64{
+		"":    1,
+		"b":   1,
+		"k":   1 << 10,
+		"kb":  1e3,
+		"kib": 1 << 10,
+		"m":   1 << 20,
+		"mb":  1e6,
+		"mib": 1 << 20,
+		"g":   1 << 30,
+		"gb":  1e9,
+		"gib": 1 << 30,
+	}
+
+	multiplier, ok := multipliers[strings.ToLower(strings.TrimSpace(value[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit in `%s`", value)
+	}
+
+	return int64(number * multiplier), nil
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int64
+		wantErr  bool
+	}{
+		{"1024", 1024, false},
+		{"3MiB", 3 << 20, false},
+		{"3 MiB", 3 << 20, false},
+		{"750KB", 750000, false},
+		{"1.5m", 3 << 19, false},
+		{"", 0, true},
+		{"3 parsecs", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseBytes(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error to be %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %s to be %d bytes, got %d", tt.value, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSizeBudgetConfigResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   SizeBudgetConfig
+		expected SizeBudget
+		wantErr  bool
+	}{
+		{"Default to the paid plan", SizeBudgetConfig{}, SizeBudget{Max: PaidPlanScriptSize}, false},
+		{"Free plan", SizeBudgetConfig{Plan: "free"}, SizeBudget{Max: FreePlanScriptSize}, false},
+		{"Override the plan", SizeBudgetConfig{Plan: "free", Max: "2MiB", Warn: "1MiB"}, SizeBudget{Max: 2 << 20, Warn: 1 << 20}, false},
+		{"Unknown plan", SizeBudgetConfig{Plan: "enterprise"}, SizeBudget{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.config.Resolve()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error to be %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && *got != tt.expected {
+				t.Errorf("Expected budget to be %+v, got %+v", tt.expected, *got)
+			}
+		})
+	}
+}
+
+func TestReadSizeBudgetConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	content := `{"name": "worker", "micromachine": {"sizeBudget": {"plan": "free", "warn": "2MiB"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := ReadSizeBudgetConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config = config.Merge(SizeBudgetConfig{Warn: "2.5MiB"})
+	expected := SizeBudgetConfig{Plan: "free", Warn: "2.5MiB"}
+	if config != expected {
+		t.Errorf("Expected config to be %+v, got %+v", expected, config)
+	}
+}
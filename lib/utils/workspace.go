@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// workspaceLockfiles are the lockfiles that mark the root of a project, in
+// addition to the ones DetectPackageManager knows about.
+var workspaceLockfiles = []string{"bun.lock", "bun.lockb", "pnpm-lock.yaml", "yarn.lock", "package-lock.json"}
+
+// Workspace is the package manager workspace, e.g. a pnpm or yarn monorepo,
+// a project belongs to.
+type Workspace struct {
+	// Root is the absolute path of the workspace root.
+	Root string
+	// Evidence is what the root was recognised by, e.g. "found
+	// `pnpm-workspace.yaml`".
+	Evidence string
+	// Package is the name of the package of the project, empty when the root
+	// does not declare its members or the project has no name.
+	Package string
+}
+
+// RelRoot returns the workspace root relative to rootDir, e.g. `../..`.
+func (w *Workspace) RelRoot(rootDir string) string {
+	absDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return w.Root
+	}
+
+	rel, err := filepath.Rel(absDir, w.Root)
+	if err != nil {
+		return w.Root
+	}
+
+	return rel
+}
+
+// FindWorkspace walks up from rootDir looking for the root of a workspace: a
+// directory with a `pnpm-workspace.yaml`, a package.json declaring
+// `workspaces`, or a lockfile. It returns nil when rootDir is a project of its
+// own, either because it has a lockfile or a `.git` of its own, or because no
+// root declares it as a member.
+func FindWorkspace(rootDir string) (*Workspace, error) {
+	absDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if isWorkspaceRoot(absDir) {
+		return nil, nil
+	}
+
+	// A repository of its own is not part of a workspace above it.
+	if _, err := os.Stat(filepath.Join(absDir, ".git")); err == nil {
+		return nil, nil
+	}
+
+	for dir := filepath.Dir(absDir); ; dir = filepath.Dir(dir) {
+		patterns, evidence, err := workspacePatterns(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		if evidence != "" {
+			rel, err := filepath.Rel(dir, absDir)
+			if err != nil {
+				return nil, err
+			}
+
+			if patterns == nil {
+				return &Workspace{Root: dir, Evidence: evidence}, nil
+			}
+
+			if !matchWorkspacePatterns(patterns, filepath.ToSlash(rel)) {
+				return nil, nil
+			}
+
+			workspace := &Workspace{Root: dir, Evidence: evidence}
+			if packageJSON, err := ReadPackageJSON(absDir); err == nil {
+				workspace.Package = packageJSON.Name
+			}
+			return workspace, nil
+		}
+
+		// Do not leave the repository of the project.
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || filepath.Dir(dir) == dir {
+			return nil, nil
+		}
+	}
+}
+
+// isWorkspaceRoot reports whether dir has a workspace declaration or a
+// lockfile of its own.
+func isWorkspaceRoot(dir string) bool {
+	_, evidence, _ := workspacePatterns(dir)
+	return evidence != ""
+}
+
+// workspacePatterns returns the member patterns declared in dir along with
+// the file they come from. The patterns are nil when dir is only recognised
+// by its lockfile, and the evidence is empty when dir is not a root at all.
+func workspacePatterns(dir string) ([]string, string, error) {
+	file, err := os.Open(filepath.Join(dir, "pnpm-workspace.yaml"))
+	if err == nil {
+		defer func() {
+			_ = file.Close()
+		}()
+		patterns, err := parsePnpmWorkspace(file)
+		if err != nil {
+			return nil, "", fmt.Errorf("could not parse pnpm-workspace.yaml: %w", err)
+		}
+		return patterns, "found `pnpm-workspace.yaml`", nil
+	}
+
+	if packageJSON, err := ReadPackageJSON(dir); err == nil && len(packageJSON.Workspaces) > 0 {
+		var patterns []string
+		if err := json.Unmarshal(packageJSON.Workspaces, &patterns); err != nil {
+			var workspaces struct {
+				Packages []string `json:"packages"`
+			}
+			if err := json.Unmarshal(packageJSON.Workspaces, &workspaces); err != nil {
+				return nil, "", fmt.Errorf("could not parse `workspaces` of package.json: %w", err)
+			}
+			patterns = workspaces.Packages
+		}
+		return append([]string{}, patterns...), "`workspaces` in package.json", nil
+	}
+
+	for _, lockfile := range workspaceLockfiles {
+		if _, err := os.Stat(filepath.Join(dir, lockfile)); err == nil {
+			return nil, "found `" + lockfile + "`", nil
+		}
+	}
+
+	return nil, "", nil
+}
+
+// parsePnpmWorkspace reads the `packages` list of a pnpm-workspace.yaml.
+func parsePnpmWorkspace(file *os.File) ([]string, error) {
+	patterns := []string{}
+	inPackages := false
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, "-") {
+			inPackages = strings.HasPrefix(trimmed, "packages:")
+			continue
+		}
+
+		if inPackages && strings.HasPrefix(trimmed, "-") {
+			pattern := strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			pattern, _, _ = strings.Cut(pattern, " #")
+			patterns = append(patterns, strings.Trim(strings.TrimSpace(pattern), `"'`))
+		}
+	}
+
+	return patterns, scanner.Err()
+}
+
+// matchWorkspacePatterns reports whether rel, a slash-separated path relative
+// to the workspace root, is matched by the patterns, honouring `!` exclusions
+// and `**`.
+func matchWorkspacePatterns(patterns []string, rel string) bool {
+	matched := false
+
+	for _, pattern := range patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(strings.TrimPrefix(pattern, "!"), "./")
+
+		if matchWorkspacePattern(pattern, rel) {
+			matched = !exclude
+		}
+	}
+
+	return matched
+}
+
+func matchWorkspacePattern(pattern, rel string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return rel == prefix || strings.HasPrefix(rel, prefix+"/") || matchWorkspacePattern(prefix, rel)
+	}
+
+	if before, after, ok := strings.Cut(pattern, "**/"); ok {
+		if !strings.HasPrefix(rel, before) {
+			return false
+		}
+		rest := strings.TrimPrefix(rel, before)
+		for {
+			if ok, _ := path.Match(after, rest); ok {
+				return true
+			}
+			_, next, found := strings.Cut(rest, "/")
+			if !found {
+				return false
+			}
+			rest = next
+		}
+	}
+
+	ok, _ := path.Match(pattern, rel)
+	return ok
+}
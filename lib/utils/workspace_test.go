@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeWorkspace(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestFindWorkspace(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		app      string
+		evidence string
+		pkg      string
+	}{
+		{
+			"pnpm workspace",
+			map[string]string{
+				"pnpm-workspace.yaml":      "packages:\n  - 'apps/*'\n  - \"packages/**\"\n",
+				"pnpm-lock.yaml":           "",
+				"apps/web/package.json":    `{"name": "@acme/web"}`,
+				"packages/ui/package.json": `{"name": "@acme/ui"}`,
+			},
+			"apps/web",
+			"found `pnpm-workspace.yaml`",
+			"@acme/web",
+		},
+		{
+			"Workspaces in package.json",
+			map[string]string{
+				"package.json":          `{"workspaces": ["apps/*"]}`,
+				"yarn.lock":             "",
+				"apps/api/package.json": `{"name": "api"}`,
+			},
+			"apps/api",
+			"`workspaces` in package.json",
+			"api",
+		},
+		{
+			"Yarn workspaces object",
+			map[string]string{
+				"package.json":          `{"workspaces": {"packages": ["apps/**"]}}`,
+				"apps/a/b/package.json": `{"name": "nested"}`,
+			},
+			"apps/a/b",
+			"`workspaces` in package.json",
+			"nested",
+		},
+		{
+			"Lockfile only",
+			map[string]string{
+				"bun.lock":              "",
+				"apps/web/package.json": `{"name": "web"}`,
+			},
+			"apps/web",
+			"found `bun.lock`",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeWorkspace(t, tt.files)
+
+			workspace, err := FindWorkspace(filepath.Join(dir, tt.app))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if workspace == nil {
+				t.Fatal("Expected a workspace, got nil")
+			}
+
+			if workspace.Root != dir {
+				t.Errorf("Expected root %s, got %s", dir, workspace.Root)
+			}
+			if workspace.Evidence != tt.evidence {
+				t.Errorf("Expected evidence %q, got %q", tt.evidence, workspace.Evidence)
+			}
+			if workspace.Package != tt.pkg {
+				t.Errorf("Expected package %q, got %q", tt.pkg, workspace.Package)
+			}
+		})
+	}
+}
+
+func TestFindWorkspaceOutsideMembers(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		app   string
+	}{
+		{"Not a member", map[string]string{"pnpm-workspace.yaml": "packages:\n  - apps/*\n", "tools/cli/package.json": `{}`}, "tools/cli"},
+		{"Excluded", map[string]string{"package.json": `{"workspaces": ["apps/*", "!apps/legacy"]}`, "apps/legacy/package.json": `{}`}, "apps/legacy"},
+		{"Own lockfile", map[string]string{"pnpm-workspace.yaml": "packages:\n  - apps/*\n", "apps/web/package-lock.json": `{}`}, "apps/web"},
+		{"Own repository", map[string]string{"pnpm-workspace.yaml": "packages:\n  - apps/*\n", "apps/web/.git/HEAD": "", "apps/web/package.json": `{}`}, "apps/web"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeWorkspace(t, tt.files)
+
+			workspace, err := FindWorkspace(filepath.Join(dir, tt.app))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if workspace != nil {
+				t.Errorf("Expected no workspace, got %+v", workspace)
+			}
+		})
+	}
+}
+
+func TestResolvePackageManagerFromWorkspace(t *testing.T) {
+	dir := writeWorkspace(t, map[string]string{
+		"pnpm-workspace.yaml":   "packages:\n  - apps/*\n",
+		"pnpm-lock.yaml":        "",
+		"apps/web/package.json": `{"name": "web"}`,
+	})
+
+	pm, evidence, err := ResolvePackageManager(filepath.Join(dir, "apps/web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "found `pnpm-lock.yaml` in the workspace root `../..`"
+	if pm.Name != "pnpm" || evidence != expected {
+		t.Errorf("Expected pnpm (%q), got %s (%q)", expected, pm, evidence)
+	}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -16,7 +17,9 @@ type WranglerConfig struct {
 	CompatibilityDate  string   `toml:"compatibility_date" json:"compatibility_date"`
 	CompatibilityFlags []string `toml:"compatibility_flags" json:"compatibility_flags,omitempty"`
 
-	NoBundle bool `json:""`
+	// NoBundle and Logpush are pointers so that an environment setting them to
+	// false overrides a top-level true.
+	NoBundle *bool `toml:"no_bundle" json:"no_bundle,omitempty"`
 
 	// Worker type
 	Type string `toml:"type" json:"type,omitempty"` // "module" or "service-worker" (deprecated)
@@ -39,12 +42,19 @@ type WranglerConfig struct {
 	Queues          *QueuesConfig       `toml:"queues" json:"queues,omitempty"`
 	Hyperdrive      []HyperdriveBinding `toml:"hyperdrive" json:"hyperdrive,omitempty"`
 	Vectorize       []VectorizeBinding  `toml:"vectorize" json:"vectorize,omitempty"`
-	AIBindings      []AIBinding         `toml:"ai" json:"ai,omitempty"`
+	Workflows       []WorkflowBinding   `toml:"workflows" json:"workflows,omitempty"`
+	AI              *AIBinding          `toml:"ai" json:"ai,omitempty"`
 
 	// Variables & secrets
 	Vars    map[string]string `toml:"vars" json:"vars,omitempty"`
 	Secrets []string          `toml:"-" json:"-"` // not in config, just for reference
 
+	// Triggers (crons, etc)
+	Triggers *TriggersConfig `toml:"triggers" json:"triggers,omitempty"`
+
+	// Durable Object migrations (top-level only)
+	Migrations []Migration `toml:"migrations" json:"migrations,omitempty"`
+
 	// Build
 	Build *BuildConfig `toml:"build" json:"build,omitempty"`
 
@@ -52,14 +62,18 @@ type WranglerConfig struct {
 	Env map[string]*WranglerConfig `toml:"env" json:"env,omitempty"`
 
 	// Observability
-	Logpush       bool           `toml:"logpush" json:"logpush,omitempty"`
-	TailConsumers []TailConsumer `toml:"tail_consumers" json:"tail_consumers,omitempty"`
+	Logpush       *bool                `toml:"logpush" json:"logpush,omitempty"`
+	TailConsumers []TailConsumer       `toml:"tail_consumers" json:"tail_consumers,omitempty"`
+	Observability *ObservabilityConfig `toml:"observability" json:"observability,omitempty"`
 
 	// Limits
 	Limits *LimitsConfig `toml:"limits" json:"limits,omitempty"`
 
 	// Placement
 	Placement *PlacementConfig `toml:"placement" json:"placement,omitempty"`
+
+	// Dev settings (top-level only)
+	Dev *DevConfig `toml:"dev" json:"dev,omitempty"`
 }
 
 type NormalizedWranglerConfig struct {
@@ -77,18 +91,23 @@ type NormalizedWranglerConfig struct {
 	LegacyEnv          bool     `json:"legacy_env,omitempty" toml:"legacy_env"`
 	NoBundle           bool     `json:"no_bundle,omitempty" toml:"no_bundle"`
 
+	// Routes & domains
+	Routes []Route `json:"routes,omitempty" toml:"routes"`
+	Route  string  `json:"route,omitempty" toml:"route"`
+
 	// JSX
 	JSXFactory  string `json:"jsx_factory,omitempty" toml:"jsx_factory"`
 	JSXFragment string `json:"jsx_fragment,omitempty" toml:"jsx_fragment"`
 
 	// Module rules
-	Rules []ModuleRule `json:"rules,omitempty" toml:"rules"`
+	Rules                 []ModuleRule `json:"rules,omitempty" toml:"rules"`
+	FindAdditionalModules bool         `json:"find_additional_modules,omitempty" toml:"find_additional_modules"`
 
 	// Assets
 	Assets *AssetsConfig `json:"assets,omitempty" toml:"assets"`
 
 	// Triggers (crons, etc)
-	Triggers TriggersConfig `json:"triggers,omitempty" toml:"triggers"`
+	Triggers TriggersConfig `json:"triggers,omitzero" toml:"triggers"`
 
 	// Variables & secrets
 	Vars map[string]string `json:"vars,omitempty" toml:"vars"`
@@ -97,13 +116,14 @@ type NormalizedWranglerConfig struct {
 	KVNamespaces            []KVNamespace        `json:"kv_namespaces,omitempty" toml:"kv_namespaces"`
 	R2Buckets               []R2Bucket           `json:"r2_buckets,omitempty" toml:"r2_buckets"`
 	D1Databases             []D1Database         `json:"d1_databases,omitempty" toml:"d1_databases"`
-	DurableObjects          DurableObjects       `json:"durable_objects,omitempty" toml:"durable_objects"`
+	DurableObjects          DurableObjects       `json:"durable_objects,omitzero" toml:"durable_objects"`
 	Services                []ServiceBinding     `json:"services,omitempty" toml:"services"`
 	AnalyticsEngineDatasets []AnalyticsBinding   `json:"analytics_engine_datasets,omitempty" toml:"analytics_engine_datasets"`
-	Queues                  QueuesConfig         `json:"queues,omitempty" toml:"queues"`
+	Queues                  QueuesConfig         `json:"queues,omitzero" toml:"queues"`
 	Hyperdrive              []HyperdriveBinding  `json:"hyperdrive,omitempty" toml:"hyperdrive"`
 	Vectorize               []VectorizeBinding   `json:"vectorize,omitempty" toml:"vectorize"`
 	Workflows               []WorkflowBinding    `json:"workflows,omitempty" toml:"workflows"`
+	AI                      *AIBinding           `json:"ai,omitempty" toml:"ai"`
 	Migrations              []Migration          `json:"migrations,omitempty" toml:"migrations"`
 	MTLSCertificates        []MTLSCertificate    `json:"mtls_certificates,omitempty" toml:"mtls_certificates"`
 	SendEmail               []SendEmailBinding   `json:"send_email,omitempty" toml:"send_email"`
@@ -116,17 +136,23 @@ type NormalizedWranglerConfig struct {
 
 	// Legacy/misc
 	Cloudchamber     json.RawMessage `json:"cloudchamber,omitempty" toml:"cloudchamber"`
-	Logfwdr          LogfwdrConfig   `json:"logfwdr,omitempty" toml:"logfwdr"`
+	Logfwdr          LogfwdrConfig   `json:"logfwdr,omitzero" toml:"logfwdr"`
 	UnsafeHelloWorld []any           `json:"unsafe_hello_world,omitempty" toml:"-"`
 
 	// Observability
-	Observability ObservabilityConfig `json:"observability,omitempty" toml:"observability"`
+	Logpush       bool                `json:"logpush,omitempty" toml:"logpush"`
+	TailConsumers []TailConsumer      `json:"tail_consumers,omitempty" toml:"tail_consumers"`
+	Observability ObservabilityConfig `json:"observability,omitzero" toml:"observability"`
+
+	// Limits & placement
+	Limits    *LimitsConfig    `json:"limits,omitempty" toml:"limits"`
+	Placement *PlacementConfig `json:"placement,omitempty" toml:"placement"`
 
 	// Python
-	PythonModules PythonModulesConfig `json:"python_modules,omitempty" toml:"python_modules"`
+	PythonModules PythonModulesConfig `json:"python_modules,omitzero" toml:"python_modules"`
 
 	// Dev settings
-	Dev DevConfig `json:"dev,omitempty" toml:"dev"`
+	Dev DevConfig `json:"dev,omitzero" toml:"dev"`
 
 	// Environments
 	Env map[string]*WranglerConfig `json:"env,omitempty" toml:"env"`
@@ -337,7 +363,9 @@ type PlacementConfig struct {
 	Mode string `toml:"mode" json:"mode,omitempty"` // "smart"
 }
 
-func DetectWranglerFile[T any](root *string) (*T, error) {
+// FindWranglerFile returns the path of the wrangler configuration file used
+// for the given root directory.
+func FindWranglerFile(root *string) (string, error) {
 	rootDir := ""
 	if root != nil {
 		rootDir = *root
@@ -349,26 +377,42 @@ func DetectWranglerFile[T any](root *string) (*T, error) {
 		filepath.Join(rootDir, "/wrangler.jsonc"),
 	}
 
-	content := ""
 	usedPath := ""
 
 	for _, path := range paths {
-		data, err := os.ReadFile(path)
+		info, err := os.Stat(path)
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) {
 				continue
 			}
-			return nil, err
+			return "", err
+		}
+
+		if info.Size() > 0 {
+			usedPath = path
 		}
+	}
+
+	if usedPath == "" {
+		return "", errors.New("no wrangler configuration file found")
+	}
 
-		usedPath = path
-		content = string(data)
+	return usedPath, nil
+}
+
+func DetectWranglerFile[T any](root *string) (*T, error) {
+	usedPath, err := FindWranglerFile(root)
+	if err != nil {
+		return nil, err
 	}
 
-	if content == "" || usedPath == "" {
-		return nil, errors.New("no wrangler configuration file found")
+	data, err := os.ReadFile(usedPath)
+	if err != nil {
+		return nil, err
 	}
 
+	content := string(data)
+
 	ext := filepath.Ext(usedPath)
 	if ext == "" {
 		return nil, errors.New("invalid wrangler configuration file")
@@ -382,7 +426,7 @@ func DetectWranglerFile[T any](root *string) (*T, error) {
 			return nil, err
 		}
 	case ".toml":
-		err := toml.Unmarshal([]byte(content), &config)
+		err := unmarshalTOML([]byte(content), &config)
 		if err != nil {
 			return nil, err
 		}
@@ -392,3 +436,15 @@ func DetectWranglerFile[T any](root *string) (*T, error) {
 
 	return config, nil
 }
+
+// unmarshalTOML wraps toml.Unmarshal, which panics instead of failing when a
+// TOML date is decoded into a string (e.g. an unquoted compatibility_date).
+func unmarshalTOML(data []byte, v any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid wrangler configuration: %v", r)
+		}
+	}()
+
+	return toml.Unmarshal(data, v)
+}
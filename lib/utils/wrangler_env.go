@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// inheritableKeys are the top-level keys an environment falls back to when it
+// does not set them. `name` is inherited with the environment name appended.
+var inheritableKeys = []string{
+	"name",
+	"main",
+	"compatibility_date",
+	"compatibility_flags",
+	"no_bundle",
+	"type",
+	"route",
+	"routes",
+	"assets",
+	"site",
+	"build",
+	"triggers",
+	"observability",
+	"logpush",
+	"limits",
+	"placement",
+}
+
+// topLevelOnlyKeys are the keys only read from the top level: an environment
+// always uses the top-level value, even when it sets its own.
+var topLevelOnlyKeys = []string{
+	"migrations",
+	"dev",
+}
+
+// IsTopLevelOnlyKey reports whether an `env.<name>` block always uses the
+// top-level value of the given key.
+func IsTopLevelOnlyKey(key string) bool {
+	return slices.Contains(topLevelOnlyKeys, key)
+}
+
+// IsInheritableKey reports whether an `env.<name>` block inherits the given
+// top-level key when it does not set it.
+func IsInheritableKey(key string) bool {
+	return slices.Contains(inheritableKeys, key)
+}
+
+// ForEnvironment returns the configuration of the `env.<name>` block merged
+// with the top-level configuration, following wrangler's inheritance rules:
+// inheritable keys fall through from the top level when the environment does
+// not set them, while bindings and vars only ever come from the environment.
+// The top-level configuration is returned as-is when no such block exists.
+func (c *WranglerConfig) ForEnvironment(name string) *WranglerConfig {
+	if c == nil || name == "" {
+		return c
+	}
+
+	block, ok := c.Env[name]
+	if !ok || block == nil {
+		if len(c.Env) > 0 {
+			LogWithColor(Muted, fmt.Sprintf("No `env.%s` block found (available: %s), using the top-level configuration", name, strings.Join(slices.Sorted(maps.Keys(c.Env)), ", ")))
+		}
+		return c
+	}
+
+	resolved := *block
+	resolved.Env = nil
+
+	if resolved.Name == "" && c.Name != "" {
+		resolved.Name = c.Name + "-" + name
+	}
+
+	if resolved.Main == "" {
+		resolved.Main = c.Main
+	}
+
+	if resolved.CompatibilityDate == "" {
+		resolved.CompatibilityDate = c.CompatibilityDate
+	}
+
+	if resolved.CompatibilityFlags == nil {
+		resolved.CompatibilityFlags = c.CompatibilityFlags
+	}
+
+	if resolved.NoBundle == nil {
+		resolved.NoBundle = c.NoBundle
+	}
+
+	if resolved.Type == "" {
+		resolved.Type = c.Type
+	}
+
+	// `route` and `routes` are mutually exclusive, so they are inherited together.
+	if resolved.Routes == nil && resolved.Route == "" {
+		resolved.Routes = c.Routes
+		resolved.Route = c.Route
+	}
+
+	if resolved.Assets == nil {
+		resolved.Assets = c.Assets
+	}
+
+	if resolved.Site == nil {
+		resolved.Site = c.Site
+	}
+
+	if resolved.Build == nil {
+		resolved.Build = c.Build
+	}
+
+	if resolved.Triggers == nil {
+		resolved.Triggers = c.Triggers
+	}
+
+	if resolved.Observability == nil {
+		resolved.Observability = c.Observability
+	}
+
+	// Migrations and dev settings can only be declared at the top level.
+	resolved.Migrations = c.Migrations
+	resolved.Dev = c.Dev
+
+	if resolved.Logpush == nil {
+		resolved.Logpush = c.Logpush
+	}
+
+	if resolved.Limits == nil {
+		resolved.Limits = c.Limits
+	}
+
+	if resolved.Placement == nil {
+		resolved.Placement = c.Placement
+	}
+
+	return &resolved
+}
@@ -0,0 +1,103 @@
+package utils
+
+import "testing"
+
+func TestWranglerConfigForEnvironment(t *testing.T) {
+	conf := &WranglerConfig{
+		Name:              "worker",
+		Main:              "src/index.ts",
+		CompatibilityDate: "2025-01-01",
+		Assets: &AssetsConfig{
+			Directory: "./public",
+		},
+		Vars: map[string]string{"STAGE": "production"},
+		KVNamespaces: []KVNamespace{
+			{Binding: "CACHE", ID: "production-id"},
+		},
+		Env: map[string]*WranglerConfig{
+			"staging": {
+				CompatibilityDate: "2025-06-01",
+				Vars:              map[string]string{"STAGE": "staging"},
+			},
+		},
+	}
+
+	got := conf.ForEnvironment("staging")
+
+	if got.Name != "worker-staging" {
+		t.Errorf("Expected name to be %s, got %s", "worker-staging", got.Name)
+	}
+
+	if got.Main != "src/index.ts" {
+		t.Errorf("Expected `main` to be inherited, got %s", got.Main)
+	}
+
+	if got.CompatibilityDate != "2025-06-01" {
+		t.Errorf("Expected `compatibility_date` to be overridden, got %s", got.CompatibilityDate)
+	}
+
+	if got.Assets == nil || got.Assets.Directory != "./public" {
+		t.Errorf("Expected `assets` to be inherited, got %v", got.Assets)
+	}
+
+	if got.Vars["STAGE"] != "staging" {
+		t.Errorf("Expected `vars` to come from the environment, got %v", got.Vars)
+	}
+
+	if len(got.KVNamespaces) != 0 {
+		t.Errorf("Expected `kv_namespaces` not to be inherited, got %v", got.KVNamespaces)
+	}
+
+	if got.Env != nil {
+		t.Errorf("Expected resolved config to have no environments, got %v", got.Env)
+	}
+}
+
+func TestWranglerConfigForMissingEnvironment(t *testing.T) {
+	conf := &WranglerConfig{
+		Name: "worker",
+		Vars: map[string]string{"STAGE": "production"},
+	}
+
+	got := conf.ForEnvironment("staging")
+	if got != conf {
+		t.Errorf("Expected the top-level configuration, got %v", got)
+	}
+}
+
+func TestWranglerConfigForEnvironmentBooleans(t *testing.T) {
+	yes, no := true, false
+
+	tests := []struct {
+		name     string
+		topLevel *bool
+		env      *bool
+		expected bool
+	}{
+		{"Inherited true", &yes, nil, true},
+		{"Overridden with false", &yes, &no, false},
+		{"Overridden with true", &no, &yes, true},
+		{"Unset", nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := &WranglerConfig{
+				Name:     "worker",
+				NoBundle: tt.topLevel,
+				Logpush:  tt.topLevel,
+				Env: map[string]*WranglerConfig{
+					"staging": {NoBundle: tt.env, Logpush: tt.env},
+				},
+			}
+
+			got := conf.ForEnvironment("staging").Normalize()
+			if got.NoBundle != tt.expected {
+				t.Errorf("Expected `no_bundle` to be %v, got %v", tt.expected, got.NoBundle)
+			}
+			if got.Logpush != tt.expected {
+				t.Errorf("Expected `logpush` to be %v, got %v", tt.expected, got.Logpush)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WranglerOrigin describes where the resolved value of a key comes from.
+type WranglerOrigin struct {
+	Key   string
+	Value any
+	// Block is `top-level` or `env.<name>`.
+	Block  string
+	Path   string
+	Line   int
+	Column int
+	// Inherited is set when an environment falls back to the top-level value.
+	Inherited bool
+	Note      string
+}
+
+// ExplainWranglerKey resolves a dotted key, e.g. `vars.API_URL` or
+// `kv_namespaces[0].id`, for the given environment and reports the file
+// location and block its value comes from.
+func ExplainWranglerKey(src *WranglerSource, env, key string) (*WranglerOrigin, error) {
+	path := splitKey(key)
+	if len(path) == 0 {
+		return nil, fmt.Errorf("invalid key %q", key)
+	}
+
+	origin := &WranglerOrigin{Key: key, Block: "top-level", Path: src.Path}
+
+	found := func(node *WranglerNode, block string) *WranglerOrigin {
+		origin.Block = block
+		origin.Value = node.Interface()
+		origin.Line, origin.Column = node.Line, node.Column
+		return origin
+	}
+
+	top := src.Root.Lookup(path...)
+
+	var envBlock *WranglerNode
+	if env != "" {
+		envBlock = src.Root.Lookup("env", env)
+	}
+
+	if envBlock == nil {
+		if env != "" {
+			origin.Note = fmt.Sprintf("there is no `env.%s` block, the top-level configuration is used", env)
+		}
+		if top == nil {
+			return nil, fmt.Errorf("`%s` is not set in %s", key, src.Path)
+		}
+		return found(top, "top-level"), nil
+	}
+
+	block := "env." + env
+	if IsTopLevelOnlyKey(path[0]) {
+		if top == nil {
+			return nil, fmt.Errorf("`%s` is not set at the top level; `%s` can only be set there, so `%s` has none", key, path[0], block)
+		}
+		found(top, "top-level")
+		origin.Inherited = true
+		origin.Note = fmt.Sprintf("`%s` can only be set at the top level, `%s` uses it", path[0], block)
+		if node := envBlock.Lookup(path...); node != nil {
+			origin.Note += fmt.Sprintf("; the value of `%s` at %s:%d is ignored", block, src.Path, node.Line)
+		}
+		return origin, nil
+	}
+
+	if node := envBlock.Lookup(path...); node != nil {
+		return found(node, block), nil
+	}
+
+	if !IsInheritableKey(path[0]) {
+		if top != nil {
+			return nil, fmt.Errorf("`%s` is not set in `%s`; `%s` is not inheritable, so the top-level value at %s:%d is ignored", key, block, path[0], src.Path, top.Line)
+		}
+		return nil, fmt.Errorf("`%s` is not set in `%s` nor at the top level", key, block)
+	}
+
+	if top == nil {
+		return nil, fmt.Errorf("`%s` is not set in `%s` nor at the top level", key, block)
+	}
+
+	found(top, "top-level")
+	origin.Inherited = true
+	origin.Note = fmt.Sprintf("inherited by `%s` from the top-level configuration", block)
+
+	if key == "name" {
+		origin.Value = fmt.Sprintf("%v-%s", top.Value, env)
+		origin.Note = fmt.Sprintf("derived from the top-level name, suffixed with `-%s`", env)
+	}
+
+	return origin, nil
+}
+
+// splitKey splits `a.b[0].c` into `a`, `b`, `0`, `c`.
+func splitKey(key string) []string {
+	key = strings.NewReplacer("[", ".", "]", "").Replace(key)
+
+	var path []string
+	for _, segment := range strings.Split(key, ".") {
+		if segment != "" {
+			path = append(path, segment)
+		}
+	}
+
+	return path
+}
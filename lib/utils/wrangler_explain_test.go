@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExplainWranglerKey(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "wrangler.toml"), []byte(`name = "test"
+main = "src/index.ts"
+
+[vars]
+STAGE = "production"
+
+[env.staging.vars]
+STAGE = "staging"
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := ParseWranglerSource(&dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origin, err := ExplainWranglerKey(src, "staging", "vars.STAGE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if origin.Value != "staging" || origin.Block != "env.staging" || origin.Line != 8 {
+		t.Errorf("Expected `vars.STAGE` from env.staging at line 8, got %+v", origin)
+	}
+
+	origin, err = ExplainWranglerKey(src, "staging", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !origin.Inherited || origin.Block != "top-level" || origin.Line != 2 {
+		t.Errorf("Expected `main` to be inherited from line 2, got %+v", origin)
+	}
+
+	origin, err = ExplainWranglerKey(src, "staging", "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if origin.Value != "test-staging" {
+		t.Errorf("Expected name to be test-staging, got %v", origin.Value)
+	}
+}
+
+func TestExplainTopLevelOnlyKey(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "wrangler.toml"), []byte(`name = "test"
+main = "src/index.ts"
+
+[dev]
+port = 8787
+
+[env.staging.dev]
+port = 9000
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := ParseWranglerSource(&dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origin, err := ExplainWranglerKey(src, "staging", "dev.port")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if origin.Block != "top-level" || origin.Line != 5 || !origin.Inherited {
+		t.Errorf("Expected `dev.port` from the top level at line 5, got %+v", origin)
+	}
+
+	conf := &WranglerConfig{
+		Dev: &DevConfig{Port: 8787},
+		Env: map[string]*WranglerConfig{"staging": {Dev: &DevConfig{Port: 9000}}},
+	}
+	if got := conf.ForEnvironment("staging").Dev.Port; got != 8787 {
+		t.Errorf("Expected ForEnvironment to use the top-level dev port, got %d", got)
+	}
+}
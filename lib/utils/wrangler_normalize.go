@@ -0,0 +1,57 @@
+package utils
+
+// Normalize converts a user wrangler configuration, typically already
+// resolved with ForEnvironment, into the flat configuration wrangler deploys.
+func (c *WranglerConfig) Normalize() *NormalizedWranglerConfig {
+	if c == nil {
+		return nil
+	}
+
+	normalized := &NormalizedWranglerConfig{
+		Name:                    c.Name,
+		Main:                    c.Main,
+		CompatibilityDate:       c.CompatibilityDate,
+		CompatibilityFlags:      c.CompatibilityFlags,
+		NoBundle:                c.NoBundle != nil && *c.NoBundle,
+		Routes:                  c.Routes,
+		Route:                   c.Route,
+		Assets:                  c.Assets,
+		Vars:                    c.Vars,
+		KVNamespaces:            c.KVNamespaces,
+		R2Buckets:               c.R2Buckets,
+		D1Databases:             c.D1Databases,
+		Services:                c.Services,
+		AnalyticsEngineDatasets: c.AnalyticsEngine,
+		Hyperdrive:              c.Hyperdrive,
+		Vectorize:               c.Vectorize,
+		Workflows:               c.Workflows,
+		AI:                      c.AI,
+		Migrations:              c.Migrations,
+		Logpush:                 c.Logpush != nil && *c.Logpush,
+		TailConsumers:           c.TailConsumers,
+		Limits:                  c.Limits,
+		Placement:               c.Placement,
+	}
+
+	if c.DurableObjects != nil {
+		normalized.DurableObjects = *c.DurableObjects
+	}
+
+	if c.Queues != nil {
+		normalized.Queues = *c.Queues
+	}
+
+	if c.Triggers != nil {
+		normalized.Triggers = *c.Triggers
+	}
+
+	if c.Observability != nil {
+		normalized.Observability = *c.Observability
+	}
+
+	if c.Dev != nil {
+		normalized.Dev = *c.Dev
+	}
+
+	return normalized
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWranglerConfigNormalize(t *testing.T) {
+	conf := &WranglerConfig{
+		Name:              "worker",
+		Main:              "src/index.ts",
+		CompatibilityDate: "2025-01-01",
+		AI:                &AIBinding{Binding: "AI"},
+		DurableObjects: &DurableObjects{
+			Bindings: []DurableObjectBinding{{Name: "COUNTER", ClassName: "Counter"}},
+		},
+	}
+
+	got := conf.Normalize()
+
+	if got.Name != "worker" || got.Main != "src/index.ts" || got.CompatibilityDate != "2025-01-01" {
+		t.Errorf("Expected core fields to be carried over, got %+v", got)
+	}
+
+	if got.AI == nil || got.AI.Binding != "AI" {
+		t.Errorf("Expected `ai` binding to be carried over, got %v", got.AI)
+	}
+
+	if len(got.DurableObjects.Bindings) != 1 {
+		t.Errorf("Expected 1 durable object binding, got %d", len(got.DurableObjects.Bindings))
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{`"queues"`, `"triggers"`, `"dev"`} {
+		if strings.Contains(string(data), key) {
+			t.Errorf("Expected empty %s to be omitted, got %s", key, data)
+		}
+	}
+}
@@ -0,0 +1,492 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"github.com/tidwall/jsonc"
+)
+
+type WranglerNodeKind int
+
+const (
+	NodeNull WranglerNodeKind = iota
+	NodeObject
+	NodeArray
+	NodeString
+	NodeNumber
+	NodeBool
+	NodeDate
+)
+
+func (k WranglerNodeKind) String() string {
+	switch k {
+	case NodeObject:
+		return "object"
+	case NodeArray:
+		return "array"
+	case NodeString:
+		return "string"
+	case NodeNumber:
+		return "number"
+	case NodeBool:
+		return "boolean"
+	case NodeDate:
+		return "date"
+	default:
+		return "null"
+	}
+}
+
+// WranglerNode is a value of a wrangler configuration file along with the
+// position it was declared at.
+type WranglerNode struct {
+	Kind WranglerNodeKind
+	// Value holds the decoded scalar for strings, numbers, booleans and dates.
+	Value  any
+	Keys   []string
+	Fields map[string]*WranglerNode
+	Items  []*WranglerNode
+	Line   int
+	Column int
+}
+
+func newObjectNode(line, column int) *WranglerNode {
+	return &WranglerNode{Kind: NodeObject, Fields: map[string]*WranglerNode{}, Line: line, Column: column}
+}
+
+func (n *WranglerNode) set(key string, value *WranglerNode) {
+	if _, ok := n.Fields[key]; !ok {
+		n.Keys = append(n.Keys, key)
+	}
+	n.Fields[key] = value
+}
+
+// Lookup follows a path of object keys and array indexes (as decimal
+// strings) and returns the node it points to, or nil.
+func (n *WranglerNode) Lookup(path ...string) *WranglerNode {
+	current := n
+	for _, segment := range path {
+		if current == nil {
+			return nil
+		}
+
+		switch current.Kind {
+		case NodeObject:
+			current = current.Fields[segment]
+		case NodeArray:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(current.Items) {
+				return nil
+			}
+			current = current.Items[i]
+		default:
+			return nil
+		}
+	}
+
+	return current
+}
+
+// Interface converts the node back into plain Go values.
+func (n *WranglerNode) Interface() any {
+	if n == nil {
+		return nil
+	}
+
+	switch n.Kind {
+	case NodeObject:
+		m := make(map[string]any, len(n.Fields))
+		for key, value := range n.Fields {
+			m[key] = value.Interface()
+		}
+		return m
+	case NodeArray:
+		items := make([]any, len(n.Items))
+		for i, item := range n.Items {
+			items[i] = item.Interface()
+		}
+		return items
+	default:
+		return n.Value
+	}
+}
+
+// WranglerSource is a wrangler configuration file parsed with positions.
+type WranglerSource struct {
+	Path string
+	Root *WranglerNode
+}
+
+// SourceError is an error located in a wrangler configuration file.
+type SourceError struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.Path, e.Line, e.Column, e.Message)
+}
+
+// ParseWranglerSource parses the wrangler configuration file of the given root
+// directory, keeping track of where every value is declared.
+func ParseWranglerSource(root *string) (*WranglerSource, error) {
+	path, err := FindWranglerFile(root)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var node *WranglerNode
+	switch filepath.Ext(path) {
+	case ".json", ".jsonc":
+		// jsonc.ToJSON keeps offsets and line breaks intact, so positions
+		// still point into the original file.
+		p := &jsonParser{path: path, data: jsonc.ToJSON(data)}
+		node, err = p.parse()
+	case ".toml":
+		node, err = parseTOMLSource(path, data)
+	default:
+		return nil, errors.New("invalid wrangler configuration file")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if node.Kind != NodeObject {
+		return nil, &SourceError{Path: path, Line: node.Line, Column: node.Column, Message: "the configuration must be an object"}
+	}
+
+	return &WranglerSource{Path: path, Root: node}, nil
+}
+
+type jsonParser struct {
+	path string
+	data []byte
+	pos  int
+}
+
+func (p *jsonParser) position(offset int) (int, int) {
+	lead := p.data[:offset]
+	return bytes.Count(lead, []byte{'\n'}) + 1, offset - bytes.LastIndexByte(lead, '\n')
+}
+
+func (p *jsonParser) errorf(format string, args ...any) error {
+	line, column := p.position(min(p.pos, len(p.data)))
+	return &SourceError{Path: p.path, Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *jsonParser) skipWhitespace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsonParser) parse() (*WranglerNode, error) {
+	p.skipWhitespace()
+	node, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipWhitespace()
+	if p.pos < len(p.data) {
+		return nil, p.errorf("unexpected %q after the configuration", p.data[p.pos])
+	}
+
+	return node, nil
+}
+
+func (p *jsonParser) parseValue() (*WranglerNode, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of file")
+	}
+
+	line, column := p.position(p.pos)
+
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		return p.parseObject(line, column)
+	case c == '[':
+		return p.parseArray(line, column)
+	case c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &WranglerNode{Kind: NodeString, Value: s, Line: line, Column: column}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.data) && strings.IndexByte("+-0123456789.eE", p.data[p.pos]) >= 0 {
+			p.pos++
+		}
+		var number json.Number
+		if err := json.Unmarshal(p.data[start:p.pos], &number); err != nil {
+			p.pos = start
+			return nil, p.errorf("invalid number")
+		}
+		return &WranglerNode{Kind: NodeNumber, Value: number, Line: line, Column: column}, nil
+	default:
+		for literal, node := range map[string]*WranglerNode{
+			"true":  {Kind: NodeBool, Value: true},
+			"false": {Kind: NodeBool, Value: false},
+			"null":  {Kind: NodeNull},
+		} {
+			if bytes.HasPrefix(p.data[p.pos:], []byte(literal)) {
+				p.pos += len(literal)
+				node.Line, node.Column = line, column
+				return node, nil
+			}
+		}
+		return nil, p.errorf("unexpected character %q", c)
+	}
+}
+
+func (p *jsonParser) parseString() (string, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '"':
+			p.pos++
+			var s string
+			if err := json.Unmarshal(p.data[start:p.pos], &s); err != nil {
+				p.pos = start
+				return "", p.errorf("invalid string")
+			}
+			return s, nil
+		case '\n':
+			return "", p.errorf("unterminated string")
+		}
+		p.pos++
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+func (p *jsonParser) parseObject(line, column int) (*WranglerNode, error) {
+	node := newObjectNode(line, column)
+	p.pos++
+
+	for {
+		p.skipWhitespace()
+		if p.pos < len(p.data) && p.data[p.pos] == '}' {
+			p.pos++
+			return node, nil
+		}
+
+		if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+			return nil, p.errorf("expected a quoted key")
+		}
+
+		keyLine, keyColumn := p.position(p.pos)
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipWhitespace()
+		if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+			return nil, p.errorf("expected ':' after key %q", key)
+		}
+		p.pos++
+		p.skipWhitespace()
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		// Report values at their key, like wrangler does.
+		value.Line, value.Column = keyLine, keyColumn
+		node.set(key, value)
+
+		p.skipWhitespace()
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == '}' {
+			p.pos++
+			return node, nil
+		}
+		return nil, p.errorf("expected ',' or '}'")
+	}
+}
+
+func (p *jsonParser) parseArray(line, column int) (*WranglerNode, error) {
+	node := &WranglerNode{Kind: NodeArray, Line: line, Column: column}
+	p.pos++
+
+	for {
+		p.skipWhitespace()
+		if p.pos < len(p.data) && p.data[p.pos] == ']' {
+			p.pos++
+			return node, nil
+		}
+
+		item, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.Items = append(node.Items, item)
+
+		p.skipWhitespace()
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ']' {
+			p.pos++
+			return node, nil
+		}
+		return nil, p.errorf("expected ',' or ']'")
+	}
+}
+
+func parseTOMLSource(path string, data []byte) (*WranglerNode, error) {
+	// Let the regular decoder report syntax errors, as it knows their position.
+	var syntax map[string]any
+	if err := toml.Unmarshal(data, &syntax); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			line, column := decodeErr.Position()
+			return nil, &SourceError{Path: path, Line: line, Column: column, Message: decodeErr.Error()}
+		}
+		return nil, err
+	}
+
+	p := &unstable.Parser{}
+	p.Reset(data)
+
+	root := newObjectNode(1, 1)
+	current := root
+
+	for p.NextExpression() {
+		expr := p.Expression()
+
+		switch expr.Kind {
+		case unstable.KeyValue:
+			keys, line, column := tomlKey(p, expr.Key())
+			parent := tomlTable(current, keys[:len(keys)-1], line, column)
+			parent.set(keys[len(keys)-1], tomlValue(p, expr.Value(), line, column))
+		case unstable.Table:
+			keys, line, column := tomlKey(p, expr.Key())
+			current = tomlTable(root, keys, line, column)
+		case unstable.ArrayTable:
+			keys, line, column := tomlKey(p, expr.Key())
+			parent := tomlTable(root, keys[:len(keys)-1], line, column)
+			last := keys[len(keys)-1]
+
+			array := parent.Fields[last]
+			if array == nil || array.Kind != NodeArray {
+				array = &WranglerNode{Kind: NodeArray, Line: line, Column: column}
+				parent.set(last, array)
+			}
+
+			current = newObjectNode(line, column)
+			array.Items = append(array.Items, current)
+		}
+	}
+
+	if err := p.Error(); err != nil {
+		return nil, &SourceError{Path: path, Line: 1, Column: 1, Message: err.Error()}
+	}
+
+	return root, nil
+}
+
+func tomlKey(p *unstable.Parser, it unstable.Iterator) ([]string, int, int) {
+	var keys []string
+	line, column := 0, 0
+
+	for it.Next() {
+		node := it.Node()
+		if line == 0 {
+			shape := p.Shape(node.Raw)
+			line, column = shape.Start.Line, shape.Start.Column
+		}
+		keys = append(keys, string(node.Data))
+	}
+
+	return keys, line, column
+}
+
+// tomlTable walks (and creates) nested tables, descending into the last
+// element of arrays of tables like TOML does.
+func tomlTable(node *WranglerNode, keys []string, line, column int) *WranglerNode {
+	for _, key := range keys {
+		next := node.Fields[key]
+		if next == nil {
+			next = newObjectNode(line, column)
+			node.set(key, next)
+		}
+
+		if next.Kind == NodeArray && len(next.Items) > 0 {
+			next = next.Items[len(next.Items)-1]
+		}
+
+		node = next
+	}
+
+	return node
+}
+
+func tomlValue(p *unstable.Parser, value *unstable.Node, line, column int) *WranglerNode {
+	if value.Raw.Length > 0 {
+		shape := p.Shape(value.Raw)
+		line, column = shape.Start.Line, shape.Start.Column
+	}
+
+	switch value.Kind {
+	case unstable.String:
+		return &WranglerNode{Kind: NodeString, Value: string(value.Data), Line: line, Column: column}
+	case unstable.Bool:
+		return &WranglerNode{Kind: NodeBool, Value: string(value.Data) == "true", Line: line, Column: column}
+	case unstable.Integer, unstable.Float:
+		return &WranglerNode{Kind: NodeNumber, Value: json.Number(strings.ReplaceAll(string(value.Data), "_", "")), Line: line, Column: column}
+	case unstable.LocalDate, unstable.LocalTime, unstable.LocalDateTime, unstable.DateTime:
+		return &WranglerNode{Kind: NodeDate, Value: string(value.Data), Line: line, Column: column}
+	case unstable.Array:
+		node := &WranglerNode{Kind: NodeArray, Line: line, Column: column}
+		it := value.Children()
+		for it.Next() {
+			node.Items = append(node.Items, tomlValue(p, it.Node(), line, column))
+		}
+		return node
+	case unstable.InlineTable:
+		node := newObjectNode(line, column)
+		it := value.Children()
+		for it.Next() {
+			kv := it.Node()
+			keys, keyLine, keyColumn := tomlKey(p, kv.Key())
+			parent := tomlTable(node, keys[:len(keys)-1], keyLine, keyColumn)
+			parent.set(keys[len(keys)-1], tomlValue(p, kv.Value(), keyLine, keyColumn))
+		}
+		return node
+	default:
+		return &WranglerNode{Kind: NodeNull, Line: line, Column: column}
+	}
+}
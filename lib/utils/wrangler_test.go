@@ -18,14 +18,7 @@ func TestWranglerDetection(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			dir := os.TempDir()
-
-			t.Cleanup(func() {
-				err := os.RemoveAll(dir)
-				if err != nil {
-					return
-				}
-			})
+			dir := t.TempDir()
 
 			switch tt.ext {
 			case ".toml":
@@ -61,7 +54,7 @@ func TestWranglerDetection(t *testing.T) {
 }
 
 func TestMissingWranglerFile(t *testing.T) {
-	dir := os.TempDir()
+	dir := t.TempDir()
 	_, err := DetectWranglerFile[WranglerConfig](&dir)
 	if err == nil {
 		t.Error("Expected error when no wrangler file found")
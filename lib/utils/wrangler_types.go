@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GenerateEnvTypes renders TypeScript declarations of the `Env` bindings of
+// the top-level configuration and of every `env.<name>` block. Secrets are
+// typed as strings; entrypoint is the path of the worker entrypoint relative to
+// the declaration file and is used to type Durable Object namespaces.
+func GenerateEnvTypes(conf *WranglerConfig, secrets []string, entrypoint string, source string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "// Generated by `micromachine types` from %s. Do not edit by hand.\n", source)
+	b.WriteString("declare namespace Cloudflare {\n")
+
+	writeEnvInterface(&b, "Env", conf, secrets, entrypoint)
+
+	for _, name := range slices.Sorted(maps.Keys(conf.Env)) {
+		b.WriteString("\n")
+		writeEnvInterface(&b, envInterfaceName(name), conf.ForEnvironment(name), secrets, entrypoint)
+	}
+
+	b.WriteString("}\n")
+	b.WriteString("interface Env extends Cloudflare.Env {}\n")
+
+	return b.String()
+}
+
+func writeEnvInterface(b *strings.Builder, name string, conf *WranglerConfig, secrets []string, entrypoint string) {
+	bindings := envBindings(conf, secrets, entrypoint)
+
+	fmt.Fprintf(b, "\tinterface %s {\n", name)
+	for _, key := range slices.Sorted(maps.Keys(bindings)) {
+		fmt.Fprintf(b, "\t\t%s: %s;\n", typeKey(key), bindings[key])
+	}
+	b.WriteString("\t}\n")
+}
+
+// envBindings maps every binding name of the configuration to its type.
+func envBindings(conf *WranglerConfig, secrets []string, entrypoint string) map[string]string {
+	bindings := map[string]string{}
+
+	for key, value := range conf.Vars {
+		bindings[key] = strconv.Quote(value)
+	}
+
+	for _, secret := range slices.Concat(conf.Secrets, secrets) {
+		bindings[secret] = "string"
+	}
+
+	for _, kv := range conf.KVNamespaces {
+		bindings[kv.Binding] = "KVNamespace"
+	}
+
+	for _, r2 := range conf.R2Buckets {
+		bindings[r2.Binding] = "R2Bucket"
+	}
+
+	for _, d1 := range conf.D1Databases {
+		bindings[d1.Binding] = "D1Database"
+	}
+
+	if conf.DurableObjects != nil {
+		for _, do := range conf.DurableObjects.Bindings {
+			if do.ScriptName == "" && entrypoint != "" {
+				bindings[do.Name] = fmt.Sprintf("DurableObjectNamespace<import(%s).%s>", strconv.Quote(entrypoint), do.ClassName)
+				continue
+			}
+			bindings[do.Name] = "DurableObjectNamespace"
+		}
+	}
+
+	for _, service := range conf.Services {
+		bindings[service.Binding] = "Fetcher"
+	}
+
+	for _, dataset := range conf.AnalyticsEngine {
+		bindings[dataset.Binding] = "AnalyticsEngineDataset"
+	}
+
+	if conf.Queues != nil {
+		for _, producer := range conf.Queues.Producers {
+			bindings[producer.Binding] = "Queue"
+		}
+	}
+
+	for _, hyperdrive := range conf.Hyperdrive {
+		bindings[hyperdrive.Binding] = "Hyperdrive"
+	}
+
+	for _, index := range conf.Vectorize {
+		bindings[index.Binding] = "VectorizeIndex"
+	}
+
+	for _, workflow := range conf.Workflows {
+		bindings[workflow.Binding] = "Workflow"
+	}
+
+	if conf.AI != nil && conf.AI.Binding != "" {
+		bindings[conf.AI.Binding] = "Ai"
+	}
+
+	if conf.Assets != nil && conf.Assets.Binding != "" {
+		bindings[conf.Assets.Binding] = "Fetcher"
+	}
+
+	delete(bindings, "")
+	return bindings
+}
+
+// envInterfaceName turns `staging-eu` into `StagingEuEnv`.
+func envInterfaceName(env string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(env, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+
+	return b.String() + "Env"
+}
+
+func typeKey(key string) string {
+	for i, r := range key {
+		if !(r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || (i > 0 && r >= '0' && r <= '9')) {
+			return strconv.Quote(key)
+		}
+	}
+
+	return key
+}
+
+// ReadDevVarsKeys returns the names of the secrets declared in `.dev.vars`,
+// which wrangler uses for local secrets.
+func ReadDevVarsKeys(rootDir string) ([]string, error) {
+	file, err := os.Open(filepath.Join(rootDir, ".dev.vars"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var keys []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, _, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if found {
+			keys = append(keys, strings.TrimSpace(key))
+		}
+	}
+
+	return keys, scanner.Err()
+}
+
+// WriteEnvTypes generates the `Env` declarations of the wrangler configuration
+// of rootDir into output, relative to rootDir, and returns the written path.
+func WriteEnvTypes(rootDir string, output string) (string, error) {
+	source, err := FindWranglerFile(&rootDir)
+	if err != nil {
+		return "", err
+	}
+
+	conf, err := DetectWranglerFile[WranglerConfig](&rootDir)
+	if err != nil {
+		return "", err
+	}
+
+	secrets, err := ReadDevVarsKeys(rootDir)
+	if err != nil {
+		return "", fmt.Errorf("could not read .dev.vars: %w", err)
+	}
+
+	path := filepath.Join(rootDir, output)
+
+	entrypoint := ""
+	if conf.Main != "" {
+		rel, err := filepath.Rel(filepath.Dir(path), filepath.Join(rootDir, conf.Main))
+		if err == nil {
+			entrypoint = filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+			if !strings.HasPrefix(entrypoint, ".") {
+				entrypoint = "./" + entrypoint
+			}
+		}
+	}
+
+	content := GenerateEnvTypes(conf, secrets, entrypoint, filepath.Base(source))
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
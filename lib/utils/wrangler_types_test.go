@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerateEnvTypes(t *testing.T) {
+	conf := &WranglerConfig{
+		Name: "worker",
+		Main: "src/index.ts",
+		Vars: map[string]string{"STAGE": "production"},
+		KVNamespaces: []KVNamespace{
+			{Binding: "CACHE", ID: "cache-id"},
+		},
+		DurableObjects: &DurableObjects{
+			Bindings: []DurableObjectBinding{
+				{Name: "COUNTER", ClassName: "Counter"},
+				{Name: "REMOTE", ClassName: "Remote", ScriptName: "other-worker"},
+			},
+		},
+		Queues: &QueuesConfig{
+			Producers: []QueueProducer{{Binding: "JOBS", Queue: "jobs"}},
+		},
+		AI: &AIBinding{Binding: "AI"},
+		Env: map[string]*WranglerConfig{
+			"staging-eu": {
+				Vars: map[string]string{"STAGE": "staging"},
+			},
+		},
+	}
+
+	got := GenerateEnvTypes(conf, []string{"API_TOKEN"}, "./src/index", "wrangler.toml")
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"var", `STAGE: "production";`},
+		{"secret", "API_TOKEN: string;"},
+		{"kv namespace", "CACHE: KVNamespace;"},
+		{"local durable object", `COUNTER: DurableObjectNamespace<import("./src/index").Counter>;`},
+		{"remote durable object", "REMOTE: DurableObjectNamespace;"},
+		{"queue producer", "JOBS: Queue;"},
+		{"ai", "AI: Ai;"},
+		{"environment interface", "interface StagingEuEnv {"},
+		{"environment var", `STAGE: "staging";`},
+		{"global env", "interface Env extends Cloudflare.Env {}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(got, tt.expected) {
+				t.Errorf("Expected declarations to contain %q, got\n%s", tt.expected, got)
+			}
+		})
+	}
+
+	if strings.Count(got, "CACHE: KVNamespace;") != 1 {
+		t.Errorf("Expected bindings not to be inherited by environments, got\n%s", got)
+	}
+}
+
+func TestReadDevVarsKeys(t *testing.T) {
+	dir := t.TempDir()
+
+	keys, err := ReadDevVarsKeys(dir)
+	if err != nil || keys != nil {
+		t.Errorf("Expected no keys without a .dev.vars file, got %v (%v)", keys, err)
+	}
+
+	content := "# local secrets\nAPI_TOKEN=secret\n\nexport DB_PASSWORD = \"hunter2\"\n"
+	if err := os.WriteFile(filepath.Join(dir, ".dev.vars"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err = ReadDevVarsKeys(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(keys, []string{"API_TOKEN", "DB_PASSWORD"}) {
+		t.Errorf("Expected keys to be %v, got %v", []string{"API_TOKEN", "DB_PASSWORD"}, keys)
+	}
+}
@@ -0,0 +1,318 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// WranglerProblem is an issue found while validating a wrangler configuration.
+type WranglerProblem struct {
+	Severity string
+	Path     string
+	Line     int
+	Column   int
+	// Key is the dotted path of the offending value, e.g. `env.staging.vars`.
+	Key     string
+	Message string
+}
+
+func (p WranglerProblem) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", p.Path, p.Line, p.Column, p.Severity, p.Message)
+}
+
+// unsupportedWranglerKeys are valid wrangler keys that micromachine does not
+// model; they are accepted but dropped from the build output.
+var unsupportedWranglerKeys = []string{
+	"account_id",
+	"alias",
+	"base_dir",
+	"browser",
+	"cloudchamber",
+	"containers",
+	"define",
+	"dispatch_namespaces",
+	"find_additional_modules",
+	"images",
+	"jsx_factory",
+	"jsx_fragment",
+	"keep_vars",
+	"legacy_env",
+	"logfwdr",
+	"minify",
+	"mtls_certificates",
+	"node_compat",
+	"pipelines",
+	"preserve_file_names",
+	"preview_urls",
+	"python_modules",
+	"ratelimits",
+	"rules",
+	"secrets_store_secrets",
+	"send_email",
+	"tsconfig",
+	"unsafe",
+	"upload_source_maps",
+	"usage_model",
+	"version_metadata",
+	"vpc_services",
+	"worker_loaders",
+	"workers_dev",
+}
+
+// requiredWranglerFields are the fields wrangler requires in the objects of a
+// configuration, by type.
+var requiredWranglerFields = map[reflect.Type][]string{
+	reflect.TypeFor[Route]():                {"pattern"},
+	reflect.TypeFor[SiteConfig]():           {"bucket"},
+	reflect.TypeFor[KVNamespace]():          {"binding"},
+	reflect.TypeFor[R2Bucket]():             {"binding", "bucket_name"},
+	reflect.TypeFor[D1Database]():           {"binding", "database_name", "database_id"},
+	reflect.TypeFor[DurableObjectBinding](): {"name", "class_name"},
+	reflect.TypeFor[ServiceBinding]():       {"binding", "service"},
+	reflect.TypeFor[AnalyticsBinding]():     {"binding"},
+	reflect.TypeFor[QueueProducer]():        {"binding", "queue"},
+	reflect.TypeFor[QueueConsumer]():        {"queue"},
+	reflect.TypeFor[HyperdriveBinding]():    {"binding", "id"},
+	reflect.TypeFor[VectorizeBinding]():     {"binding", "index_name"},
+	reflect.TypeFor[WorkflowBinding]():      {"binding", "name", "class_name"},
+	reflect.TypeFor[AIBinding]():            {"binding"},
+	reflect.TypeFor[Migration]():            {"tag"},
+	reflect.TypeFor[RenamedClass]():         {"from", "to"},
+	reflect.TypeFor[TailConsumer]():         {"service"},
+}
+
+// ValidateWranglerSource reports unknown keys, values of the wrong type and
+// missing required fields in a wrangler configuration file.
+func ValidateWranglerSource(src *WranglerSource) []WranglerProblem {
+	v := &wranglerValidator{path: src.Path}
+	configType := reflect.TypeFor[WranglerConfig]()
+
+	v.checkStruct(src.Root, configType, "", true)
+
+	if env := src.Root.Fields["env"]; env != nil && env.Kind == NodeObject {
+		for _, name := range env.Keys {
+			v.checkStruct(env.Fields[name], configType, "env."+name, false)
+		}
+	}
+
+	// Required fields of the top level, which environments inherit.
+	for _, key := range []string{"name", "compatibility_date"} {
+		if src.Root.Fields[key] == nil {
+			v.report(SeverityError, src.Root, key, fmt.Sprintf("missing required field `%s`", key))
+		}
+	}
+
+	if src.Root.Fields["main"] == nil && src.Root.Fields["assets"] == nil {
+		v.report(SeverityError, src.Root, "main", "missing required field `main` (or `assets` for an assets-only worker)")
+	}
+
+	slices.SortStableFunc(v.problems, func(a, b WranglerProblem) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+
+	return v.problems
+}
+
+type wranglerValidator struct {
+	path     string
+	problems []WranglerProblem
+}
+
+func (v *wranglerValidator) report(severity string, node *WranglerNode, key, message string) {
+	v.problems = append(v.problems, WranglerProblem{
+		Severity: severity,
+		Path:     v.path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Key:      key,
+		Message:  message,
+	})
+}
+
+// checkStruct validates an object against a configuration struct. Top-level
+// and environment blocks skip the required-field check, since their fields
+// may be inherited.
+func (v *wranglerValidator) checkStruct(node *WranglerNode, t reflect.Type, key string, topLevel bool) {
+	if node.Kind != NodeObject {
+		v.report(SeverityError, node, key, fmt.Sprintf("`%s` must be an object, got %s", key, node.Kind))
+		return
+	}
+
+	fields := configFields(t)
+	isConfig := t == reflect.TypeFor[WranglerConfig]()
+
+	for _, name := range node.Keys {
+		child := node.Fields[name]
+		childKey := joinKey(key, name)
+
+		if topLevel && name == "$schema" {
+			continue
+		}
+
+		if isConfig && name == "env" {
+			if !topLevel {
+				v.report(SeverityError, child, childKey, "environments cannot be nested")
+			}
+			continue
+		}
+
+		field, ok := fields[name]
+		if !ok {
+			switch {
+			case isConfig && slices.Contains(unsupportedWranglerKeys, name):
+				v.report(SeverityWarning, child, childKey, fmt.Sprintf("`%s` is not supported by micromachine and will be ignored", childKey))
+			case suggestKey(name, fields) != "":
+				v.report(SeverityWarning, child, childKey, fmt.Sprintf("unknown key `%s`, did you mean `%s`?", childKey, suggestKey(name, fields)))
+			default:
+				v.report(SeverityWarning, child, childKey, fmt.Sprintf("unknown key `%s`", childKey))
+			}
+			continue
+		}
+
+		v.checkValue(child, field.Type, childKey)
+	}
+
+	if isConfig {
+		return
+	}
+
+	for _, name := range requiredWranglerFields[t] {
+		if _, ok := node.Fields[name]; !ok {
+			v.report(SeverityError, node, joinKey(key, name), fmt.Sprintf("missing required field `%s` in `%s`", name, key))
+		}
+	}
+}
+
+func (v *wranglerValidator) checkValue(node *WranglerNode, t reflect.Type, key string) {
+	if node.Kind == NodeNull {
+		return
+	}
+
+	if t == reflect.TypeFor[json.RawMessage]() || t.Kind() == reflect.Interface {
+		return
+	}
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	expected := ""
+
+	switch t.Kind() {
+	case reflect.Struct:
+		v.checkStruct(node, t, key, false)
+		return
+	case reflect.Slice:
+		if node.Kind != NodeArray {
+			expected = "an array"
+			break
+		}
+		for i, item := range node.Items {
+			v.checkValue(item, t.Elem(), fmt.Sprintf("%s[%d]", key, i))
+		}
+		return
+	case reflect.Map:
+		if node.Kind != NodeObject {
+			expected = "an object"
+			break
+		}
+		for _, name := range node.Keys {
+			v.checkValue(node.Fields[name], t.Elem(), joinKey(key, name))
+		}
+		return
+	case reflect.String:
+		if node.Kind != NodeString {
+			expected = "a string"
+		}
+	case reflect.Bool:
+		if node.Kind != NodeBool {
+			expected = "a boolean"
+		}
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		if node.Kind != NodeNumber {
+			expected = "a number"
+		}
+	}
+
+	if expected != "" {
+		message := fmt.Sprintf("`%s` must be %s, got %s", key, expected, node.Kind)
+		if node.Kind == NodeDate && t.Kind() == reflect.String {
+			message += " (quote the value)"
+		}
+		v.report(SeverityError, node, key, message)
+	}
+}
+
+// configFields maps the configuration keys of a struct to its fields.
+func configFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			name, _, _ = strings.Cut(field.Tag.Get("toml"), ",")
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field
+	}
+
+	return fields
+}
+
+func joinKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// suggestKey returns the known key closest to an unknown one, if any is
+// close enough to be a likely typo.
+func suggestKey(key string, fields map[string]reflect.StructField) string {
+	best := ""
+	bestDistance := len(key)/3 + 1
+
+	for candidate := range fields {
+		if d := levenshtein(key, candidate); d < bestDistance || (d == bestDistance && best != "" && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+
+	return best
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateWranglerSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected []WranglerProblem
+	}{
+		{
+			"Validate wrangler.jsonc",
+			"wrangler.jsonc",
+			`{
+	// comment
+	"name": "test",
+	"main": "src/index.ts",
+	"compatability_date": "2025-01-01",
+	"kv_namespaces": [{"id": "abc"}],
+	"logpush": "yes"
+}`,
+			[]WranglerProblem{
+				{Severity: SeverityError, Line: 1, Key: "compatibility_date"},
+				{Severity: SeverityWarning, Line: 5, Key: "compatability_date"},
+				{Severity: SeverityError, Line: 6, Key: "kv_namespaces[0].binding"},
+				{Severity: SeverityError, Line: 7, Key: "logpush"},
+			},
+		},
+		{
+			"Validate wrangler.toml",
+			"wrangler.toml",
+			`name = "test"
+main = "src/index.ts"
+compatibility_date = 2025-01-01
+
+[env.staging]
+workers_dev = true
+
+[[env.staging.r2_buckets]]
+binding = "BUCKET"
+`,
+			[]WranglerProblem{
+				{Severity: SeverityError, Line: 3, Key: "compatibility_date"},
+				{Severity: SeverityWarning, Line: 6, Key: "env.staging.workers_dev"},
+				{Severity: SeverityError, Line: 8, Key: "env.staging.r2_buckets[0].bucket_name"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			src, err := ParseWranglerSource(&dir)
+			if err != nil {
+				t.Fatalf("expected wrangler source, got error: %v", err)
+			}
+
+			got := ValidateWranglerSource(src)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %d problem(s), got %d: %v", len(tt.expected), len(got), got)
+			}
+
+			for i, want := range tt.expected {
+				if got[i].Severity != want.Severity || got[i].Line != want.Line || got[i].Key != want.Key {
+					t.Errorf("Expected %s at line %d for `%s`, got %v", want.Severity, want.Line, want.Key, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRequiredWranglerFields(t *testing.T) {
+	for configType, names := range requiredWranglerFields {
+		fields := configFields(configType)
+		for _, name := range names {
+			if _, ok := fields[name]; !ok {
+				t.Errorf("Required field `%s` is not a field of %s", name, configType.Name())
+			}
+		}
+	}
+}